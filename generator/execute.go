@@ -0,0 +1,438 @@
+package generator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"open-template/internal/manifest"
+)
+
+// ConflictPolicy decides what happens to files that already exist in the project directory.
+type ConflictPolicy string
+
+const (
+	Fail      ConflictPolicy = "fail"      // stop with an error
+	Skip      ConflictPolicy = "skip"      // keep the existing file
+	Overwrite ConflictPolicy = "overwrite" // replace it
+)
+
+// ConflictPolicies lists the valid policies.
+var ConflictPolicies = []ConflictPolicy{Fail, Skip, Overwrite}
+
+// CancelPolicy decides what a cancelled Execute leaves behind.
+type CancelPolicy string
+
+const (
+	Rollback CancelPolicy = "rollback" // remove the files and directories it created
+	Keep     CancelPolicy = "keep"     // leave every completed operation in place
+)
+
+// hookKillDelay bounds how long a cancelled hook may keep its output open after being killed.
+const hookKillDelay = 2 * time.Second
+
+// Options controls Execute.
+type Options struct {
+	OnConflict ConflictPolicy // Fail if empty
+	OnCancel   CancelPolicy   // Rollback if empty
+	Observer   Observer       // nil means no reporting
+
+	// Journal records each completed operation in a file next to the project directory
+	// (see JournalPath), so an interrupted run can be finished by Resume. The journal is
+	// only kept when the run is interrupted and OnCancel is Keep; the error then wraps
+	// ErrResumable.
+	Journal bool
+
+	// Approve, if set, is called with the plan before anything is done and may remove its
+	// hooks, e.g. those the user has not approved; an error stops the run. Resume plans the
+	// run itself, so this is how its caller vets the hooks.
+	Approve func(plan *Plan) error
+}
+
+// ErrResumable is wrapped by the error of a run that was interrupted and left its journal
+// behind, so Resume can finish it.
+var ErrResumable = errors.New("the generation can be resumed")
+
+// resumableError marks an error as ErrResumable without changing its message.
+type resumableError struct{ error }
+
+func (e resumableError) Unwrap() error { return e.error }
+
+func (e resumableError) Is(target error) bool { return target == ErrResumable }
+
+// Result describes a finished operation.
+type Result struct {
+	Bytes    int64  // bytes written by Copy
+	SHA256   string // hex checksum of the file written by Copy
+	Duration time.Duration
+	Skipped  bool // the destination existed and was left alone
+	Resumed  bool // completed by the interrupted run and verified in place
+}
+
+// Execute carries out the plan, creating plan.Dest if needed, and reports each step to opts.Observer.
+// It stops at the first failure, or when ctx is done, and returns the error it reported through Failed.
+// When ctx is done, the running hook's process group is killed and the partial file being written is
+// removed; opts.OnCancel decides whether the completed operations are rolled back.
+func Execute(ctx context.Context, plan *Plan, opts Options) error {
+	return run(ctx, plan, opts, nil)
+}
+
+// Resume finishes the interrupted generation of the project in dest from its journal.
+// Operations the interrupted run completed are verified, their files against the journal's
+// checksums, and redone if they no longer match; the run then continues from the first
+// incomplete operation. The conflict policy of the interrupted run applies, except that a
+// file it had started writing is replaced. The hooks are those of the template's manifest,
+// unless the interrupted run skipped them; Resume refuses to continue if the template's
+// files or hooks changed in the meantime. Errors are reported like those of Execute.
+func Resume(ctx context.Context, dest string, opts Options) error {
+	fail := func(err error) error {
+		if opts.Observer != nil {
+			opts.Observer.Failed(err)
+		}
+		return err
+	}
+	header, state, err := readJournal(dest)
+	if err != nil {
+		return fail(err)
+	}
+	plan, err := NewPlan(ctx, header.Template, dest, header.Vars)
+	if err != nil {
+		return fail(err)
+	}
+	if fingerprint(plan) != header.Plan {
+		return fail(fmt.Errorf("template %s changed since the interrupted run; remove %s and generate it again", header.Template, dest))
+	}
+	// The journal only says which hooks were to run; what runs comes from the manifest.
+	hooks := make([]string, len(plan.Hooks))
+	for i, op := range plan.Hooks {
+		hooks[i] = op.Hook
+	}
+	switch {
+	case len(header.Hooks) == 0:
+		plan.Hooks = nil
+	case !slices.Equal(hooks, header.Hooks):
+		return fail(fmt.Errorf("the hooks of template %s changed since the interrupted run; remove %s and generate it again", header.Template, dest))
+	}
+
+	slog.Info("resuming generation", "dest", dest, "completed", len(state.done))
+	opts.OnConflict = header.OnConflict
+	opts.Journal = true
+	return run(ctx, plan, opts, state)
+}
+
+// run carries out the plan, skipping what the interrupted run in resume completed, if any.
+func run(ctx context.Context, plan *Plan, opts Options, resume *resumeState) (err error) {
+	obs := opts.Observer
+	if obs == nil {
+		obs = NopObserver{}
+	}
+	policy := opts.OnConflict
+	if policy == "" {
+		policy = Fail
+	}
+
+	start := time.Now()
+	var created []string // paths that did not exist before, in creation order
+	var j *journal
+	defer func() {
+		interrupted := err != nil && ctx.Err() != nil
+		if interrupted {
+			err = cancelled(plan, opts.OnCancel, created, ctx.Err())
+		}
+		// Keep the journal only if the run was interrupted and kept its files to resume from;
+		// a rolled back run has nothing left, even in a project directory that existed before.
+		// A run that failed on its own would fail the same way when resumed.
+		if j != nil {
+			if interrupted && opts.OnCancel == Keep {
+				j.close()
+				err = resumableError{err}
+			} else {
+				j.remove()
+			}
+		}
+		if err != nil {
+			slog.Info("generation failed", "dest", plan.Dest, "error", err)
+			obs.Failed(err)
+			return
+		}
+		slog.Info("project generated", "dest", plan.Dest, "duration", time.Since(start))
+		obs.Finished(time.Since(start))
+	}()
+
+	if opts.Approve != nil {
+		if err := opts.Approve(plan); err != nil {
+			return err
+		}
+	}
+	slog.Info("generating project", "template", plan.Template, "dest", plan.Dest, "ops", len(plan.Ops), "hooks", len(plan.Hooks), "on_conflict", policy)
+	obs.Planned(plan)
+	if _, err := os.Lstat(plan.Dest); err != nil {
+		created = append(created, plan.Dest)
+	}
+	if err := os.MkdirAll(plan.Dest, 0755); err != nil {
+		return err
+	}
+	if opts.Journal {
+		if resume != nil {
+			j, err = appendJournal(plan.Dest)
+		} else {
+			j, err = createJournal(plan, policy)
+		}
+		if err != nil {
+			return fmt.Errorf("opening journal: %w", err)
+		}
+	}
+
+	first := 0
+	if resume != nil {
+		first = resume.firstIncomplete()
+	}
+	for i, op := range append(slices.Clip(plan.Ops), plan.Hooks...) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		opStart := time.Now()
+		opPolicy := policy
+		if resume != nil {
+			if i < first && resume.verify(plan, i, op) {
+				obs.OpFinished(op, Result{Duration: time.Since(opStart), Resumed: true})
+				continue
+			}
+			// Whatever the interrupted run left there is incomplete or changed.
+			if i < first || resume.begun[i] {
+				opPolicy = Overwrite
+			}
+		}
+
+		obs.OpStarted(op)
+		var res Result
+		if op.Kind == Hook {
+			if err := runHook(ctx, plan, op, obs); err != nil {
+				return err
+			}
+		} else {
+			if j != nil && op.Kind == Copy {
+				if err := j.write(journalEntry{Index: i, Started: true}); err != nil {
+					return err
+				}
+			}
+			dest := filepath.Join(plan.Dest, op.Path)
+			_, statErr := os.Lstat(dest)
+			if res, err = apply(ctx, plan, op, opPolicy, obs); err != nil {
+				return err
+			}
+			if statErr != nil {
+				created = append(created, dest)
+			}
+		}
+		if j != nil {
+			if err := j.write(journalEntry{Index: i, Path: op.Path, SHA256: res.SHA256, Skipped: res.Skipped}); err != nil {
+				return err
+			}
+		}
+		res.Duration = time.Since(opStart)
+		obs.OpFinished(op, res)
+		slog.Debug("processed operation", "op", op.Kind, "path", op.Source, "hook", op.Hook, "duration", res.Duration)
+	}
+	return nil
+}
+
+// cancelled handles a cancelled Execute according to policy and returns the error reporting it.
+func cancelled(plan *Plan, policy CancelPolicy, created []string, cause error) error {
+	if policy == Keep {
+		return fmt.Errorf("generation cancelled; completed files kept in %s: %w", plan.Dest, cause)
+	}
+
+	// Remove in reverse order so directories are empty by the time they are reached.
+	// Directories still holding files written by hooks are left alone, except the project
+	// directory itself when this run created it.
+	for i := len(created) - 1; i >= 0; i-- {
+		path := created[i]
+		var err error
+		if path == plan.Dest {
+			err = os.RemoveAll(path)
+		} else {
+			err = os.Remove(path)
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("rollback incomplete", "path", path, "error", err)
+		}
+	}
+	slog.Info("rolled back cancelled generation", "dest", plan.Dest, "removed", len(created))
+	return fmt.Errorf("generation cancelled; rolled back: %w", cause)
+}
+
+// apply carries out a directory or file operation.
+func apply(ctx context.Context, plan *Plan, op Op, policy ConflictPolicy, obs Observer) (Result, error) {
+	dest := filepath.Join(plan.Dest, op.Path)
+	if op.Kind == Mkdir {
+		return Result{}, os.MkdirAll(dest, 0755)
+	}
+
+	if _, err := os.Lstat(dest); err == nil {
+		obs.Conflict(op, policy)
+		switch policy {
+		case Fail:
+			return Result{}, fmt.Errorf("%s already exists", dest)
+		case Skip:
+			return Result{Skipped: true}, nil
+		}
+	}
+	vars := plan.Vars
+	if !op.Render {
+		vars = nil
+	}
+	return copyFile(ctx, filepath.Join(plan.Template, op.Source), dest, vars)
+}
+
+// copyFile copies a file from src to dst, rendering template placeholders with vars in text
+// files unless vars is nil.
+// It returns the number of bytes written and their checksum. A partially written dst is removed.
+func copyFile(ctx context.Context, src, dst string, vars map[string]string) (res Result, err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return res, err
+	}
+	defer in.Close()
+
+	// Ensure the destination directory exists.
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return res, err
+	}
+
+	// Only text files that are rendered are read into memory; everything else is streamed.
+	var r io.Reader = contextReader{ctx, in}
+	if vars != nil {
+		br := bufio.NewReaderSize(r, manifest.SniffSize)
+		head, err := br.Peek(manifest.SniffSize)
+		if err != nil && err != io.EOF {
+			return res, err
+		}
+		r = br
+		if manifest.IsText(head) {
+			data, err := io.ReadAll(br)
+			if err != nil {
+				return res, err
+			}
+			text, err := manifest.Render(src, string(data), vars)
+			if err != nil {
+				return res, err
+			}
+			r = contextReader{ctx, strings.NewReader(text)}
+		}
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return res, err
+	}
+	defer func() {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(dst)
+		}
+	}()
+	h := sha256.New()
+	if res.Bytes, err = io.Copy(io.MultiWriter(out, h), r); err != nil {
+		return res, err
+	}
+	res.SHA256 = hex.EncodeToString(h.Sum(nil))
+	return res, nil
+}
+
+// contextReader stops reading once its context is done, so large files are interrupted promptly.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// runHook runs a hook with sh in the project directory, reporting each line it writes.
+// The template variables are passed as OPEN_TEMPLATE_<NAME> environment variables.
+// When ctx is done, the hook's whole process group is killed.
+func runHook(ctx context.Context, plan *Plan, op Op, obs Observer) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", op.Hook)
+	cmd.Dir = plan.Dest
+	cmd.Env = os.Environ()
+	for name, value := range plan.Vars {
+		cmd.Env = append(cmd.Env, HookEnvName(name)+"="+value)
+	}
+	stdout := &lineWriter{line: func(line string) { obs.HookOutput(op, "stdout", line) }}
+	stderr := &lineWriter{line: func(line string) { obs.HookOutput(op, "stderr", line) }}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	killProcessGroup(cmd)
+	// Background processes started by the hook may hold its output open; don't wait for them forever.
+	cmd.WaitDelay = hookKillDelay
+
+	err := cmd.Run()
+	stdout.flush()
+	stderr.flush()
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("hook %q: %w", op.Hook, err)
+	}
+	return nil
+}
+
+// lineWriter calls line for each complete line written to it.
+type lineWriter struct {
+	buf  []byte
+	line func(string)
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		w.line(strings.TrimSuffix(string(w.buf[:i]), "\r"))
+		w.buf = w.buf[i+1:]
+	}
+}
+
+// flush reports the last line if it has no trailing newline.
+func (w *lineWriter) flush() {
+	if len(w.buf) > 0 {
+		w.line(string(w.buf))
+		w.buf = nil
+	}
+}
+
+// HookEnvName returns the environment variable passing a template variable to hooks,
+// e.g. OPEN_TEMPLATE_PROJECT_NAME for ProjectName.
+func HookEnvName(name string) string {
+	var b strings.Builder
+	b.WriteString("OPEN_TEMPLATE_")
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) && !unicode.IsUpper(rune(name[i-1])) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package generator
+
+import "os/exec"
+
+// killProcessGroup keeps the default cancellation: without process groups, only the
+// hook's shell is killed.
+func killProcessGroup(cmd *exec.Cmd) {}
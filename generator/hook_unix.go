@@ -0,0 +1,17 @@
+//go:build unix
+
+package generator
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killProcessGroup runs the hook in its own process group and makes cancellation kill the
+// whole group, so commands started by the hook are not orphaned.
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -0,0 +1,194 @@
+package generator
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// journalHeader is the first line of a journal: what is needed to plan the run again.
+type journalHeader struct {
+	Template   string            `json:"template"`
+	Vars       map[string]string `json:"vars"`
+	Hooks      []string          `json:"hooks,omitempty"`
+	OnConflict ConflictPolicy    `json:"on_conflict"`
+	Plan       string            `json:"plan"` // fingerprint of the operations
+}
+
+// journalEntry records the progress of one operation, by its index among the plan's
+// operations followed by its hooks.
+type journalEntry struct {
+	Index   int    `json:"i"`
+	Started bool   `json:"started,omitempty"` // written before a file is copied
+	Path    string `json:"path,omitempty"`
+	SHA256  string `json:"sha256,omitempty"` // checksum of the copied file
+	Skipped bool   `json:"skipped,omitempty"`
+}
+
+// journal appends progress records to the journal file of a run.
+type journal struct {
+	f *os.File
+}
+
+// JournalPath returns the journal file kept next to the project directory dest while it is generated.
+func JournalPath(dest string) string {
+	dest = filepath.Clean(dest)
+	return filepath.Join(filepath.Dir(dest), "."+filepath.Base(dest)+".open-template-journal")
+}
+
+// createJournal starts the journal of a new run.
+func createJournal(plan *Plan, policy ConflictPolicy) (*journal, error) {
+	f, err := os.OpenFile(JournalPath(plan.Dest), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+	j := &journal{f: f}
+	hooks := make([]string, len(plan.Hooks))
+	for i, op := range plan.Hooks {
+		hooks[i] = op.Hook
+	}
+	template, err := filepath.Abs(plan.Template)
+	if err != nil {
+		template = plan.Template
+	}
+	header := journalHeader{Template: template, Vars: plan.Vars, Hooks: hooks, OnConflict: policy, Plan: fingerprint(plan)}
+	if err := j.write(header); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return j, nil
+}
+
+// appendJournal continues the journal of an interrupted run.
+func appendJournal(dest string) (*journal, error) {
+	f, err := os.OpenFile(JournalPath(dest), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &journal{f: f}, nil
+}
+
+func (j *journal) write(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = j.f.Write(append(data, '\n'))
+	return err
+}
+
+func (j *journal) close() error {
+	return j.f.Close()
+}
+
+// remove closes and deletes the journal, once the run no longer needs resuming.
+func (j *journal) remove() error {
+	j.f.Close()
+	return os.Remove(j.f.Name())
+}
+
+// fingerprint identifies the operations of a plan, so a resumed run can tell the template changed.
+func fingerprint(plan *Plan) string {
+	h := sha256.New()
+	for _, op := range plan.Ops {
+		fmt.Fprintf(h, "%s\x00%s\x00%s\x00%t\n", op.Kind, op.Source, op.Path, op.Render)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// resumeState is what a journal says about an interrupted run.
+type resumeState struct {
+	done  map[int]journalEntry // completed operations by index
+	begun map[int]bool         // copies started, possibly left partial
+}
+
+// firstIncomplete returns the index of the first operation the interrupted run did not complete.
+func (s *resumeState) firstIncomplete() int {
+	i := 0
+	for {
+		if _, ok := s.done[i]; !ok {
+			return i
+		}
+		i++
+	}
+}
+
+// readJournal reads the journal next to dest.
+func readJournal(dest string) (*journalHeader, *resumeState, error) {
+	f, err := os.Open(JournalPath(dest))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, fmt.Errorf("no interrupted generation of %s: %w", dest, err)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var header journalHeader
+	line, err := r.ReadBytes('\n')
+	if err != nil || json.Unmarshal(line, &header) != nil {
+		return nil, nil, fmt.Errorf("%s: invalid journal header", f.Name())
+	}
+	state := &resumeState{done: map[int]journalEntry{}, begun: map[int]bool{}}
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			// A torn last line was written when the run was killed; it is as if it never started.
+			return &header, state, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		var e journalEntry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, nil, fmt.Errorf("%s: invalid journal entry: %w", f.Name(), err)
+		}
+		if e.Started {
+			state.begun[e.Index] = true
+		} else {
+			state.done[e.Index] = e
+		}
+	}
+}
+
+// verify reports whether a completed operation's result is still in place.
+func (s *resumeState) verify(plan *Plan, i int, op Op) bool {
+	e, ok := s.done[i]
+	if !ok {
+		return false
+	}
+	dest := filepath.Join(plan.Dest, op.Path)
+	switch {
+	case op.Kind == Hook:
+		return true
+	case op.Kind == Mkdir:
+		info, err := os.Stat(dest)
+		return err == nil && info.IsDir()
+	case e.Skipped:
+		_, err := os.Lstat(dest)
+		return err == nil
+	}
+	sum, err := fileChecksum(dest)
+	return err == nil && sum == e.SHA256
+}
+
+// fileChecksum returns the hex SHA-256 of the file at path.
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,155 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// cancelAfter is an Observer cancelling the run once n operations finished.
+type cancelAfter struct {
+	NopObserver
+	n      int
+	cancel context.CancelFunc
+}
+
+func (o *cancelAfter) OpFinished(Op, Result) {
+	if o.n--; o.n == 0 {
+		o.cancel()
+	}
+}
+
+// interrupt generates a project from the template in src, cancelling it after n operations.
+func interrupt(t *testing.T, src, dest string, n int, onCancel CancelPolicy, withHooks bool) error {
+	t.Helper()
+	plan, err := NewPlan(context.Background(), src, dest, ProjectVars("billing"))
+	if err != nil {
+		t.Fatalf("NewPlan: %v", err)
+	}
+	if !withHooks {
+		plan.Hooks = nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	return Execute(ctx, plan, Options{OnCancel: onCancel, Journal: true, Observer: &cancelAfter{n: n, cancel: cancel}})
+}
+
+// journalTemplate is the template the journal tests generate from.
+var journalTemplate = map[string]string{
+	"template.json": `{"name": "svc", "hooks": ["touch hook-ran"]}`,
+	"a.txt":         "a",
+	"b.txt":         "b",
+	"c.txt":         "c",
+}
+
+func TestResume(t *testing.T) {
+	tests := []struct {
+		name       string
+		withHooks  bool
+		tamper     func(t *testing.T, src, dest string) // between the interrupted run and Resume
+		wantErr    string                               // part of Resume's error, "" for success
+		wantHookOK bool                                 // whether the hook ran
+	}{
+		{name: "finishes the run", withHooks: true, wantHookOK: true},
+		{name: "keeps skipped hooks skipped"},
+		{
+			name:      "redoes changed files",
+			withHooks: true,
+			tamper: func(t *testing.T, src, dest string) {
+				writeFiles(t, dest, map[string]string{"a.txt": "edited"})
+			},
+			wantHookOK: true,
+		},
+		{
+			name:      "refuses changed hooks",
+			withHooks: true,
+			tamper: func(t *testing.T, src, dest string) {
+				writeFiles(t, src, map[string]string{"template.json": `{"name": "svc", "hooks": ["touch other"]}`})
+			},
+			wantErr: "hooks of template",
+		},
+		{
+			name:      "refuses changed files",
+			withHooks: true,
+			tamper: func(t *testing.T, src, dest string) {
+				writeFiles(t, src, map[string]string{"d.txt": "d"})
+			},
+			wantErr: "changed since the interrupted run",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, dest := t.TempDir(), filepath.Join(t.TempDir(), "billing")
+			writeFiles(t, src, journalTemplate)
+
+			err := interrupt(t, src, dest, 1, Keep, tt.withHooks)
+			if !errors.Is(err, context.Canceled) || !errors.Is(err, ErrResumable) {
+				t.Fatalf("interrupted run = %v, want a resumable cancellation", err)
+			}
+			if _, err := os.Stat(JournalPath(dest)); err != nil {
+				t.Fatalf("journal of the interrupted run: %v", err)
+			}
+			if tt.tamper != nil {
+				tt.tamper(t, src, dest)
+			}
+
+			err = Resume(context.Background(), dest, Options{})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Resume = %v, want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resume: %v", err)
+			}
+			for _, name := range []string{"a", "b", "c"} {
+				if data, err := os.ReadFile(filepath.Join(dest, name+".txt")); string(data) != name {
+					t.Errorf("%s.txt = %q, %v; want %q", name, data, err, name)
+				}
+			}
+			if _, err := os.Stat(filepath.Join(dest, "hook-ran")); (err == nil) != tt.wantHookOK {
+				t.Errorf("hook ran = %v, want %v", err == nil, tt.wantHookOK)
+			}
+			if _, err := os.Stat(JournalPath(dest)); !os.IsNotExist(err) {
+				t.Errorf("journal left after the project was finished: %v", err)
+			}
+		})
+	}
+}
+
+func TestJournalKeptOnlyForInterruptedRuns(t *testing.T) {
+	tests := []struct {
+		name      string
+		existing  bool // a.txt exists in the project directory, failing the run with Fail
+		dirExists bool // the project directory exists, with an unrelated file
+		onCancel  CancelPolicy
+	}{
+		{"rolled back", false, false, Rollback},
+		{"rolled back in an existing directory", false, true, Rollback},
+		{"failed on its own", true, false, Keep},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, dest := t.TempDir(), filepath.Join(t.TempDir(), "billing")
+			writeFiles(t, src, journalTemplate)
+			if tt.existing {
+				writeFiles(t, dest, map[string]string{"a.txt": "mine"})
+			}
+			if tt.dirExists {
+				writeFiles(t, dest, map[string]string{"notes.txt": "mine"})
+			}
+
+			err := interrupt(t, src, dest, 2, tt.onCancel, true)
+			if err == nil || errors.Is(err, ErrResumable) {
+				t.Fatalf("run = %v, want an error that cannot be resumed", err)
+			}
+			if _, err := os.Stat(JournalPath(dest)); !os.IsNotExist(err) {
+				t.Errorf("journal kept: %v", err)
+			}
+		})
+	}
+}
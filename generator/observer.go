@@ -0,0 +1,163 @@
+package generator
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// Observer is notified of each step of Execute. HookOutput may be called from several
+// goroutines at once; the other methods are called from the goroutine running Execute.
+type Observer interface {
+	Planned(plan *Plan)
+	OpStarted(op Op)
+	OpFinished(op Op, res Result)
+	Conflict(op Op, policy ConflictPolicy) // the destination of a Copy exists
+	HookOutput(op Op, stream, line string) // stream is "stdout" or "stderr"
+	Failed(err error)                      // generation stopped; nothing else follows
+	Finished(elapsed time.Duration)        // generation succeeded
+}
+
+// NopObserver ignores every notification. Embed it to implement only some methods of Observer.
+type NopObserver struct{}
+
+func (NopObserver) Planned(*Plan)                 {}
+func (NopObserver) OpStarted(Op)                  {}
+func (NopObserver) OpFinished(Op, Result)         {}
+func (NopObserver) Conflict(Op, ConflictPolicy)   {}
+func (NopObserver) HookOutput(Op, string, string) {}
+func (NopObserver) Failed(error)                  {}
+func (NopObserver) Finished(time.Duration)        {}
+
+// EventType identifies the kind of an event.
+type EventType string
+
+const (
+	EventPlan       EventType = "plan"        // operations computed; Ops and Hooks are set
+	EventOpStart    EventType = "op_start"    // an operation begins
+	EventOpDone     EventType = "op_done"     // an operation finished; Bytes and DurationMS are set
+	EventConflict   EventType = "conflict"    // the destination exists; Policy is set
+	EventHookOutput EventType = "hook_output" // one line written by a hook
+	EventError      EventType = "error"       // generation failed; Resumable tells whether Resume can finish it
+	EventDone       EventType = "done"        // generation succeeded; DurationMS covers the whole run
+)
+
+// Event is an Observer notification as a flat value, in the form written by JSONL.
+type Event struct {
+	Type       EventType      `json:"type"`
+	Time       time.Time      `json:"time"`
+	Op         OpKind         `json:"op,omitempty"`
+	Path       string         `json:"path,omitempty"`   // destination path, relative to the project directory
+	Hook       string         `json:"hook,omitempty"`   // hook command line
+	Stream     string         `json:"stream,omitempty"` // stdout or stderr of a hook
+	Line       string         `json:"line,omitempty"`
+	Policy     ConflictPolicy `json:"policy,omitempty"`
+	Skipped    bool           `json:"skipped,omitempty"` // the destination existed and was left alone
+	Resumed    bool           `json:"resumed,omitempty"` // completed by an interrupted run and verified
+	SHA256     string         `json:"sha256,omitempty"`
+	Bytes      int64          `json:"bytes,omitempty"`
+	DurationMS float64        `json:"duration_ms,omitempty"`
+	Ops        int            `json:"ops,omitempty"`
+	Hooks      int            `json:"hooks,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	Resumable  bool           `json:"resumable,omitempty"` // the error wraps ErrResumable
+}
+
+// Message returns the human-readable line for the event, or "" if it has none.
+func (e Event) Message() string {
+	switch e.Type {
+	case EventOpStart:
+		if e.Op == Hook {
+			return "Running hook: " + e.Hook
+		}
+	case EventOpDone:
+		switch {
+		case e.Resumed && e.Op == Hook:
+			return "Already ran hook: " + e.Hook
+		case e.Resumed:
+			return "Already done: " + e.Path
+		case e.Skipped:
+			return "Skipped existing file: " + e.Path
+		case e.Op == Mkdir:
+			return "Created directory: " + e.Path
+		case e.Op == Copy:
+			return "Copied file: " + e.Path
+		}
+	case EventHookOutput:
+		return e.Line
+	case EventError:
+		return "Error: " + e.Error
+	}
+	return ""
+}
+
+// Events returns an Observer converting each notification into an Event passed to fn.
+// Calls to fn are serialized.
+func Events(fn func(Event)) Observer {
+	return &eventObserver{fn: fn}
+}
+
+type eventObserver struct {
+	mu sync.Mutex
+	fn func(Event)
+}
+
+func (o *eventObserver) emit(e Event) {
+	e.Time = time.Now()
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.fn(e)
+}
+
+func (o *eventObserver) Planned(p *Plan) {
+	o.emit(Event{Type: EventPlan, Ops: len(p.Ops), Hooks: len(p.Hooks)})
+}
+
+func (o *eventObserver) OpStarted(op Op) {
+	o.emit(Event{Type: EventOpStart, Op: op.Kind, Path: op.Path, Hook: op.Hook})
+}
+
+func (o *eventObserver) OpFinished(op Op, res Result) {
+	o.emit(Event{Type: EventOpDone, Op: op.Kind, Path: op.Path, Hook: op.Hook,
+		Skipped: res.Skipped, Resumed: res.Resumed, Bytes: res.Bytes, SHA256: res.SHA256, DurationMS: milliseconds(res.Duration)})
+}
+
+func (o *eventObserver) Conflict(op Op, policy ConflictPolicy) {
+	o.emit(Event{Type: EventConflict, Op: op.Kind, Path: op.Path, Policy: policy})
+}
+
+func (o *eventObserver) HookOutput(op Op, stream, line string) {
+	o.emit(Event{Type: EventHookOutput, Op: op.Kind, Hook: op.Hook, Stream: stream, Line: line})
+}
+
+func (o *eventObserver) Failed(err error) {
+	o.emit(Event{Type: EventError, Error: err.Error(), Resumable: errors.Is(err, ErrResumable)})
+}
+
+func (o *eventObserver) Finished(elapsed time.Duration) {
+	o.emit(Event{Type: EventDone, DurationMS: milliseconds(elapsed)})
+}
+
+// milliseconds converts d for Event.DurationMS.
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// JSONL returns an Observer writing each event to w as one line of JSON.
+func JSONL(w io.Writer) Observer {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false) // hooks are shell commands; keep > and & readable
+	return Events(func(e Event) { enc.Encode(e) })
+}
+
+// Lines returns an Observer printing the message of each event to w.
+// Errors are left to the caller, which gets them from Execute.
+func Lines(w io.Writer) Observer {
+	return Events(func(e Event) {
+		if msg := e.Message(); msg != "" && e.Type != EventError {
+			io.WriteString(w, msg+"\n")
+		}
+	})
+}
@@ -0,0 +1,122 @@
+// Package generator creates projects from templates.
+//
+// NewPlan computes the operations generating a project; Execute carries them out and
+// reports every step to an Observer. The TUI, the headless new command and other Go
+// programs all generate projects through this package.
+package generator
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"open-template/internal/ignore"
+	"open-template/internal/manifest"
+)
+
+// ProjectNameVar is the template variable holding the name of the generated project.
+const ProjectNameVar = manifest.ProjectNameVar
+
+// OpKind is the kind of an operation.
+type OpKind string
+
+const (
+	Mkdir OpKind = "mkdir" // create a directory
+	Copy  OpKind = "copy"  // copy a file, rendering placeholders in text files if Render is set
+	Hook  OpKind = "hook"  // run a shell command in the project directory
+)
+
+// Op is a single step of a plan.
+type Op struct {
+	Kind   OpKind
+	Source string // path relative to the template, for Mkdir and Copy
+	Path   string // rendered path relative to the project directory, for Mkdir and Copy
+	Render bool   // render the placeholders in the contents of a text file, for Copy
+	Hook   string // command line, run with sh as written in the manifest, for Hook
+}
+
+// Plan describes how a project is generated from a template.
+type Plan struct {
+	Template string            // template directory
+	Dest     string            // project directory
+	Vars     map[string]string // values substituted into paths and contents, and passed to hooks
+	Ops      []Op              // directories and files, in walk order
+	Hooks    []Op              // run in order once every file is written
+}
+
+// ProjectVars returns the template variables for a project named name.
+func ProjectVars(name string) map[string]string {
+	return map[string]string{ProjectNameVar: name}
+}
+
+// NewPlan walks the template in templateDir and plans the generation of a project in destDir.
+// The plan's Vars are vars completed with the defaults of the manifest's variables; a declared
+// variable left without a value, or a value for an undeclared one, is an error (see
+// manifest.Manifest.Values).
+// Placeholders are rendered as manifest.Rendered says: in every text file and path of a
+// template with a manifest, and only in *.tmpl files of one without. The template's manifest
+// and entries such as .git (see ignore.Always) are not copied; the manifest's hooks become
+// the plan's hooks. Hooks are not rendered: values reach them
+// only through the environment (see HookEnvName), so they cannot inject shell syntax. The
+// walk stops early, returning ctx's error, once ctx is done.
+func NewPlan(ctx context.Context, templateDir, destDir string, vars map[string]string) (*Plan, error) {
+	start := time.Now()
+	defer func() { slog.Debug("planned copy operations", "source", templateDir, "duration", time.Since(start)) }()
+
+	m, err := manifest.Load(templateDir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	hasManifest := m != nil
+	if vars, err = m.Values(vars); err != nil {
+		return nil, err
+	}
+
+	p := &Plan{Template: templateDir, Dest: destDir, Vars: vars}
+	err = filepath.WalkDir(templateDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(templateDir, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." || rel == manifest.FileName {
+			return nil
+		}
+		if ignore.Always(d.Name()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		dest, err := manifest.OutputPath(rel, d.IsDir(), hasManifest, vars)
+		if err != nil {
+			return err
+		}
+		op := Op{Kind: Copy, Source: rel, Path: dest, Render: manifest.Rendered(rel, hasManifest)}
+		if d.IsDir() {
+			op = Op{Kind: Mkdir, Source: rel, Path: dest}
+		}
+		p.Ops = append(p.Ops, op)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !hasManifest {
+		return p, nil
+	}
+	for _, hook := range m.Hooks {
+		p.Hooks = append(p.Hooks, Op{Kind: Hook, Hook: hook})
+	}
+	return p, nil
+}
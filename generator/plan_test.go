@@ -0,0 +1,156 @@
+package generator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles creates the files, given by slash-separated path, below dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGenerateRendersOnlyOptedInFiles(t *testing.T) {
+	const workflow = "steps:\n  - run: docker build -t app:${{ github.sha }} .\n"
+
+	tests := []struct {
+		name     string
+		template map[string]string
+		want     map[string]string // generated files
+	}{
+		{
+			name: "no manifest",
+			template: map[string]string{
+				".git/HEAD":                 "ref: refs/heads/main\n",
+				".github/workflows/ci.yml":  workflow,
+				"{{.ProjectName}}.txt":      "{{.ProjectName}}",
+				"README.md.tmpl":            "# {{.ProjectName}}\n",
+				"charts/values.yaml":        "image: {{ .Values.image }}\n",
+				"templates/index.html.tmpl": "<h1>{{.ProjectName}}</h1>",
+			},
+			want: map[string]string{
+				".github/workflows/ci.yml": workflow,
+				"{{.ProjectName}}.txt":     "{{.ProjectName}}",
+				"README.md":                "# billing\n",
+				"charts/values.yaml":       "image: {{ .Values.image }}\n",
+				"templates/index.html":     "<h1>billing</h1>",
+			},
+		},
+		{
+			name: "manifest",
+			template: map[string]string{
+				"template.json":            `{"name": "svc"}`,
+				".github/workflows/ci.yml": `run: echo ${{"{{"}} github.sha }}`,
+				"cmd/{{.ProjectName}}.go":  "package {{.ProjectName}}",
+				"README.md.tmpl":           "# {{.ProjectName}}\n",
+				"vendor/lib/.git":          "gitdir: ../../.git/modules/lib\n",
+				"logo.bin":                 "\x00{{.Missing}}" + strings.Repeat("x", 10000),
+			},
+			want: map[string]string{
+				".github/workflows/ci.yml": "run: echo ${{ github.sha }}",
+				"logo.bin":                 "\x00{{.Missing}}" + strings.Repeat("x", 10000),
+				"cmd/billing.go":           "package billing",
+				"README.md":                "# billing\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, dest := t.TempDir(), filepath.Join(t.TempDir(), "billing")
+			writeFiles(t, src, tt.template)
+
+			plan, err := NewPlan(context.Background(), src, dest, ProjectVars("billing"))
+			if err != nil {
+				t.Fatalf("NewPlan: %v", err)
+			}
+			if err := Execute(context.Background(), plan, Options{}); err != nil {
+				t.Fatalf("Execute: %v", err)
+			}
+
+			got := map[string]string{}
+			filepath.WalkDir(dest, func(path string, d os.DirEntry, err error) error {
+				if err == nil && d.Type().IsRegular() {
+					rel, _ := filepath.Rel(dest, path)
+					data, _ := os.ReadFile(path)
+					got[filepath.ToSlash(rel)] = string(data)
+				}
+				return err
+			})
+			if len(got) != len(tt.want) {
+				t.Errorf("generated %d files, want %d: %v", len(got), len(tt.want), got)
+			}
+			for rel, want := range tt.want {
+				if got[rel] != want {
+					t.Errorf("%s = %q, want %q", rel, got[rel], want)
+				}
+			}
+		})
+	}
+}
+
+func TestHooksReceiveValuesOnlyThroughTheEnvironment(t *testing.T) {
+	src, dest := t.TempDir(), filepath.Join(t.TempDir(), "billing")
+	writeFiles(t, src, map[string]string{
+		"template.json": `{"name": "svc", "hooks": [
+			"printf '%s' \"$OPEN_TEMPLATE_PROJECT_NAME\" > name.txt",
+			"echo {{.ProjectName}} > raw.txt"
+		]}`,
+	})
+	name := "p$(touch INJECTED)"
+
+	plan, err := NewPlan(context.Background(), src, dest, ProjectVars(name))
+	if err != nil {
+		t.Fatalf("NewPlan: %v", err)
+	}
+	if err := Execute(context.Background(), plan, Options{}); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	for file, want := range map[string]string{"name.txt": name, "raw.txt": "{{.ProjectName}}\n"} {
+		if data, err := os.ReadFile(filepath.Join(dest, file)); err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v; want %q", file, data, err, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dest, "INJECTED")); err == nil {
+		t.Error("the project name was run as a command")
+	}
+}
+
+func TestNewPlanNeedsEveryVariable(t *testing.T) {
+	src, dest := t.TempDir(), filepath.Join(t.TempDir(), "billing")
+	writeFiles(t, src, map[string]string{
+		"template.json": `{"name": "svc", "variables": [{"name": "Author"}, {"name": "Port", "default": "8080"}]}`,
+		"README.md":     "{{.ProjectName}} by {{.Author}} on {{.Port}}\n",
+	})
+
+	if _, err := NewPlan(context.Background(), src, dest, ProjectVars("billing")); err == nil || !strings.Contains(err.Error(), `"Author"`) {
+		t.Fatalf("NewPlan without Author = %v, want an error naming it", err)
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Errorf("project directory created by a failed plan: %v", err)
+	}
+
+	vars := ProjectVars("billing")
+	vars["Author"] = "Jane"
+	plan, err := NewPlan(context.Background(), src, dest, vars)
+	if err != nil {
+		t.Fatalf("NewPlan: %v", err)
+	}
+	if err := Execute(context.Background(), plan, Options{}); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dest, "README.md")); string(data) != "billing by Jane on 8080\n" {
+		t.Errorf("README.md = %q, want the given value and the default", data)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"open-template/generator"
+	"open-template/internal/paths"
+	"open-template/internal/trust"
+)
+
+// hookCommands returns the command lines of the plan's hooks.
+func hookCommands(plan *generator.Plan) []string {
+	hooks := make([]string, len(plan.Hooks))
+	for i, op := range plan.Hooks {
+		hooks[i] = op.Hook
+	}
+	return hooks
+}
+
+// openTrust opens the store of approved hooks.
+func openTrust() (*trust.Store, error) {
+	path, err := paths.TrustFile()
+	if err != nil {
+		return nil, err
+	}
+	return trust.Open(path)
+}
+
+// hooksTrusted reports whether the user approved the plan's hooks in an earlier run.
+// A plan without hooks needs no approval.
+func hooksTrusted(plan *generator.Plan) (bool, error) {
+	if len(plan.Hooks) == 0 {
+		return true, nil
+	}
+	store, err := openTrust()
+	if err != nil {
+		return false, err
+	}
+	return store.Trusted(plan.Template, hookCommands(plan)), nil
+}
+
+// trustHooks records the user's approval of the plan's hooks, so later runs of the
+// template run them without asking again.
+func trustHooks(plan *generator.Plan) error {
+	if len(plan.Hooks) == 0 {
+		return nil
+	}
+	store, err := openTrust()
+	if err != nil {
+		return err
+	}
+	if err := store.Trust(plan.Template, hookCommands(plan)); err != nil {
+		return err
+	}
+	return store.Save()
+}
+
+// hookApproval returns the generator.Options.Approve of a headless command: with skip the
+// hooks never run; with run they do and are remembered as approved; otherwise they only run
+// if they were approved before. Hooks left out for lack of approval are listed on w.
+func hookApproval(skip, run bool, w io.Writer) func(*generator.Plan) error {
+	return func(plan *generator.Plan) error {
+		switch {
+		case skip:
+			plan.Hooks = nil
+			return nil
+		case run:
+			return trustHooks(plan)
+		}
+		trusted, err := hooksTrusted(plan)
+		if err != nil || trusted {
+			return err
+		}
+		fmt.Fprintf(w, "Skipping the hooks of %s, which you have not approved:\n", filepath.Base(plan.Template))
+		for _, hook := range hookCommands(plan) {
+			fmt.Fprintf(w, "  %s\n", hook)
+		}
+		fmt.Fprintln(w, "Pass --run-hooks to run them.")
+		plan.Hooks = nil
+		return nil
+	}
+}
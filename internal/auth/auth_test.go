@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWhoAmI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != WhoAmIPath {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Header.Get("Authorization") {
+		case "Bearer good":
+			w.Write([]byte(`{"username": "ada"}`))
+		case "Bearer broken":
+			http.Error(w, "oops", http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		registry string
+		token    string
+		want     string
+		wantErr  bool
+	}{
+		{"valid token", srv.URL, "good", "ada", false},
+		{"trailing slash", srv.URL + "/", "good", "ada", false},
+		{"invalid token", srv.URL, "bad", "", true},
+		{"server error", srv.URL, "broken", "", true},
+		{"invalid URL", "ftp://example.com", "good", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := WhoAmI(tt.registry, tt.token)
+			if got != tt.want || (err != nil) != tt.wantErr {
+				t.Errorf("WhoAmI = %q, %v; want %q, error %v", got, err, tt.want, tt.wantErr)
+			}
+			if invalid := errors.Is(err, ErrInvalidToken); invalid != (tt.token == "bad") {
+				t.Errorf("errors.Is(%v, ErrInvalidToken) = %v", err, invalid)
+			}
+		})
+	}
+}
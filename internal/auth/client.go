@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// WhoAmIPath is the endpoint, relative to the registry URL, used to validate tokens.
+// It must answer GET requests carrying "Authorization: Bearer <token>" with
+// 200 and a JSON body {"username": "..."} for a valid token, or 401 for an invalid one.
+const WhoAmIPath = "/api/v1/whoami"
+
+// ErrInvalidToken is returned when the registry rejects a token.
+var ErrInvalidToken = errors.New("the registry rejected the token")
+
+// httpClient is used for registry requests.
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+// WhoAmI validates token against the registry and returns the user it belongs to.
+func WhoAmI(registry, token string) (string, error) {
+	base, err := Normalize(registry)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest(http.MethodGet, base+WhoAmIPath, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	slog.Debug("validated registry token", "registry", base, "status", resp.StatusCode)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return "", ErrInvalidToken
+	default:
+		return "", fmt.Errorf("registry answered %s", resp.Status)
+	}
+
+	var body struct {
+		Username string `json:"username"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("decoding registry response: %w", err)
+	}
+	return body.Username, nil
+}
@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Credential is the token stored for one registry.
+type Credential struct {
+	Token    string `json:"token"`
+	Username string `json:"username,omitempty"`
+}
+
+// Store holds registry credentials, keyed by normalized registry URL.
+// It is persisted as JSON readable only by the current user.
+type Store struct {
+	path       string
+	Registries map[string]Credential `json:"registries"`
+}
+
+// Open loads the credential store at path. A missing file yields an empty store.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, Registries: map[string]Credential{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if s.Registries == nil {
+		s.Registries = map[string]Credential{}
+	}
+	return s, nil
+}
+
+// Save writes the store back to disk with 0600 permissions.
+func (s *Store) Save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Write to a private temp file first so the token is never world-readable, even briefly.
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".credentials-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// Path returns the file the store is persisted to.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Set stores the credential for a registry.
+func (s *Store) Set(registry string, c Credential) error {
+	key, err := Normalize(registry)
+	if err != nil {
+		return err
+	}
+	s.Registries[key] = c
+	return nil
+}
+
+// Delete removes the credential of a registry and reports whether there was one.
+func (s *Store) Delete(registry string) bool {
+	key, err := Normalize(registry)
+	if err != nil {
+		return false
+	}
+	_, ok := s.Registries[key]
+	delete(s.Registries, key)
+	return ok
+}
+
+// List returns the registries with stored credentials, in order.
+func (s *Store) List() []string {
+	names := make([]string, 0, len(s.Registries))
+	for name := range s.Registries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the credential whose registry URL is the longest prefix of rawURL.
+// This lets a registry token authorize every repository below it.
+func (s *Store) Lookup(rawURL string) (string, Credential, bool) {
+	target, err := Normalize(rawURL)
+	if err != nil {
+		return "", Credential{}, false
+	}
+	best := ""
+	for registry := range s.Registries {
+		if (target == registry || strings.HasPrefix(target, registry+"/")) && len(registry) > len(best) {
+			best = registry
+		}
+	}
+	if best == "" {
+		return "", Credential{}, false
+	}
+	return best, s.Registries[best], true
+}
+
+// Normalize turns a registry URL into the key it is stored under: lower-case scheme and host,
+// no trailing slash, query or fragment. A URL without a scheme is assumed to be https.
+func Normalize(rawURL string) (string, error) {
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid registry URL %q", rawURL)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("unsupported registry URL scheme %q", u.Scheme)
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	path = strings.TrimSuffix(path, ".git")
+	return strings.ToLower(u.Scheme) + "://" + strings.ToLower(u.Host) + path, nil
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in, want string
+		wantErr  bool
+	}{
+		{"https://Templates.Example.com/", "https://templates.example.com", false},
+		{"templates.example.com", "https://templates.example.com", false},
+		{"http://localhost:8080/org/repo.git", "http://localhost:8080/org/repo", false},
+		{"https://example.com/a?x=1#y", "https://example.com/a", false},
+		{"ssh://git@example.com/repo", "", true},
+		{"https://", "", true},
+	}
+	for _, tt := range tests {
+		got, err := Normalize(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("Normalize(%q) = %q, %v; want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	s := &Store{Registries: map[string]Credential{
+		"https://example.com":     {Token: "site"},
+		"https://example.com/org": {Token: "org"},
+	}}
+	tests := []struct {
+		url, wantRegistry, wantToken string
+	}{
+		{"https://example.com/org/repo.git", "https://example.com/org", "org"},
+		{"https://EXAMPLE.com/org", "https://example.com/org", "org"},
+		{"https://example.com/organization/repo", "https://example.com", "site"},
+		{"https://example.com", "https://example.com", "site"},
+		{"https://example.org/org", "", ""},
+		{"git@example.com:org/repo.git", "", ""},
+	}
+	for _, tt := range tests {
+		registry, c, ok := s.Lookup(tt.url)
+		if registry != tt.wantRegistry || c.Token != tt.wantToken || ok != (tt.wantRegistry != "") {
+			t.Errorf("Lookup(%q) = %q, %q, %v; want %q, %q", tt.url, registry, c.Token, ok, tt.wantRegistry, tt.wantToken)
+		}
+	}
+}
+
+func TestStoreSaveOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "open-template", "credentials.json")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open of a missing file: %v", err)
+	}
+	if err := s.Set("https://example.com/", Credential{Token: "t", Username: "ada"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := info.Mode().Perm(); perm != 0600 {
+			t.Errorf("credentials file mode = %v, want 0600", perm)
+		}
+	}
+
+	s, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if c := s.Registries["https://example.com"]; c.Token != "t" || c.Username != "ada" {
+		t.Errorf("reopened credential = %+v", c)
+	}
+	if !s.Delete("https://EXAMPLE.com") || len(s.List()) != 0 {
+		t.Errorf("Delete left %q", s.List())
+	}
+}
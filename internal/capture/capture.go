@@ -0,0 +1,207 @@
+package capture
+
+import (
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"open-template/internal/ignore"
+	"open-template/internal/manifest"
+)
+
+// Options controls how a directory is captured as a template.
+type Options struct {
+	// Name is the name of the new template directory under the template root.
+	Name string
+
+	// ProjectName is the name of the captured project. Defaults to the base name of the source.
+	ProjectName string
+
+	// ReplaceName replaces occurrences of ProjectName in file contents and paths
+	// with the {{.ProjectName}} placeholder.
+	ReplaceName bool
+}
+
+// Result summarises a capture.
+type Result struct {
+	Dir          string // path of the new template
+	Files        int    // number of files captured
+	Skipped      int    // number of entries skipped because of .gitignore
+	Replacements int    // number of project name occurrences replaced
+	Manifest     *manifest.Manifest
+}
+
+// Capture copies the project at src into a new template under root.
+// It honours the project's .gitignore files, never copies .git, and writes a starter manifest
+// listing the variables the captured template uses.
+func Capture(src, root string, opts Options) (*Result, error) {
+	src, err := filepath.Abs(src)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(src)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", src)
+	}
+
+	if opts.ProjectName == "" {
+		opts.ProjectName = filepath.Base(src)
+	}
+	if opts.Name == "" {
+		opts.Name = opts.ProjectName
+	}
+	if strings.ContainsRune(opts.Name, filepath.Separator) || opts.Name == "." || opts.Name == ".." {
+		return nil, fmt.Errorf("invalid template name %q", opts.Name)
+	}
+
+	dest := filepath.Join(root, opts.Name)
+	if _, err := os.Lstat(dest); err == nil {
+		return nil, fmt.Errorf("template %q already exists in %s", opts.Name, root)
+	}
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return nil, err
+	}
+
+	res := &Result{Dir: dest}
+	ignored := ignore.New()
+	placeholder := manifest.Placeholder(manifest.ProjectNameVar)
+
+	// replace escapes existing template delimiters and swaps the project name for its placeholder.
+	replace := func(text string) string {
+		text = manifest.Escape(text)
+		if opts.ReplaceName {
+			res.Replacements += strings.Count(text, opts.ProjectName)
+			text = strings.ReplaceAll(text, opts.ProjectName, placeholder)
+		}
+		return text
+	}
+
+	err = filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return ignored.AddFile(src, rel)
+		}
+
+		// .git is never copied, and not counted as ignored either.
+		if ignore.Always(d.Name()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ignored.Match(rel, d.IsDir()) {
+			slog.Debug("skipping ignored path", "path", rel)
+			res.Skipped++
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// A captured template brings its own manifest; don't copy a stale one.
+		if rel == manifest.FileName {
+			return nil
+		}
+
+		target := filepath.Join(dest, replace(rel))
+		switch {
+		case d.IsDir():
+			if err := ignored.AddFile(src, rel); err != nil {
+				return err
+			}
+			return os.MkdirAll(target, 0755)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			res.Files++
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			res.Files++
+			return captureFile(path, target, replace)
+		}
+		return nil
+	})
+	if err != nil {
+		os.RemoveAll(dest)
+		return nil, err
+	}
+
+	slog.Info("captured template", "source", src, "dest", dest, "files", res.Files,
+		"skipped", res.Skipped, "replacements", res.Replacements)
+	res.Manifest = starterManifest(opts.Name, dest)
+	if err := manifest.Save(dest, res.Manifest); err != nil {
+		os.RemoveAll(dest)
+		return nil, err
+	}
+	return res, nil
+}
+
+// captureFile copies a single file, rewriting its contents if it is text.
+func captureFile(src, dst string, replace func(string) string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if manifest.IsText(data) {
+		data = []byte(replace(string(data)))
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, info.Mode().Perm())
+}
+
+// starterManifest builds a manifest declaring every variable used in the captured template.
+func starterManifest(name, dir string) *manifest.Manifest {
+	m := &manifest.Manifest{Name: name}
+	seen := map[string]bool{}
+	add := func(fields []string) {
+		for _, f := range fields {
+			if seen[f] {
+				continue
+			}
+			seen[f] = true
+			v := manifest.Variable{Name: f}
+			if f == manifest.ProjectNameVar {
+				v.Description = "Name of the generated project"
+			}
+			m.Variables = append(m.Variables, v)
+		}
+	}
+
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, path)
+		if fields, err := manifest.Fields(rel, rel); err == nil {
+			add(fields)
+		}
+		if d.Type().IsRegular() {
+			if data, err := os.ReadFile(path); err == nil && manifest.IsText(data) {
+				if fields, err := manifest.Fields(rel, string(data)); err == nil {
+					add(fields)
+				}
+			}
+		}
+		return nil
+	})
+	return m
+}
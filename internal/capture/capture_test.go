@@ -0,0 +1,79 @@
+package capture
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCapture(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "billing")
+	files := map[string]string{
+		".gitignore":        "*.log\nbuild/\n",
+		".git/HEAD":         "ref: refs/heads/main\n",
+		"main.go":           "package billing // {{ not a placeholder }}\n",
+		"billing.yaml":      "name: billing\n",
+		"debug.log":         "ignored\n",
+		"build/out":         "ignored\n",
+		"docs/billing.md":   "# billing\n",
+		"docs/.gitignore":   "draft.md\n",
+		"docs/draft.md":     "ignored\n",
+		"template.json":     `{"name": "stale"}`,
+		"cmd/billing/a.txt": "billing",
+	}
+	for rel, content := range files {
+		path := filepath.Join(src, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	root := t.TempDir()
+	res, err := Capture(src, root, Options{Name: "svc", ReplaceName: true})
+	if err != nil {
+		t.Fatalf("Capture: %v", err)
+	}
+	if res.Skipped != 3 {
+		t.Errorf("Skipped = %d, want 3 (debug.log, build, docs/draft.md; .git is not counted)", res.Skipped)
+	}
+
+	// The project name is replaced in paths and contents; existing delimiters are escaped.
+	want := map[string]string{
+		".gitignore":                 "*.log\nbuild/\n",
+		"main.go":                    `package {{.ProjectName}} // {{"{{"}} not a placeholder }}` + "\n",
+		"{{.ProjectName}}.yaml":      "name: {{.ProjectName}}\n",
+		"docs/{{.ProjectName}}.md":   "# {{.ProjectName}}\n",
+		"docs/.gitignore":            "draft.md\n",
+		"cmd/{{.ProjectName}}/a.txt": "{{.ProjectName}}",
+	}
+	for rel, content := range want {
+		data, err := os.ReadFile(filepath.Join(res.Dir, filepath.FromSlash(rel)))
+		if err != nil {
+			t.Errorf("%s: %v", rel, err)
+		} else if string(data) != content {
+			t.Errorf("%s = %q, want %q", rel, data, content)
+		}
+	}
+	for _, rel := range []string{".git", "debug.log", "build", "docs/draft.md"} {
+		if _, err := os.Lstat(filepath.Join(res.Dir, filepath.FromSlash(rel))); err == nil {
+			t.Errorf("%s was captured, want it skipped", rel)
+		}
+	}
+
+	if res.Manifest.Name != "svc" || len(res.Manifest.Variables) != 1 || res.Manifest.Variables[0].Name != "ProjectName" {
+		t.Errorf("manifest = %+v, want svc declaring ProjectName only", res.Manifest)
+	}
+}
+
+func TestCaptureExistingTemplate(t *testing.T) {
+	src, root := t.TempDir(), t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "svc"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Capture(src, root, Options{Name: "svc"}); err == nil {
+		t.Error("Capture into an existing template succeeded")
+	}
+}
@@ -1,50 +1,147 @@
 package config
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+
+	"open-template/internal/paths"
 )
 
 // Config holds the application configuration.
 type Config struct {
-	Help    bool
-	Depth   int
-	Verbose bool
-	Command string // Parsed command
+	Help         bool
+	Depth        int
+	Verbose      bool
+	TemplateDirs []string
+	SyncRemote   string
+	LogLevel     string
+	UI           string
+	OnCancel     string
+
+	Command string   // Parsed command
+	Args    []string // Arguments following the command
+
+	// ConfigFile is the config file that was read, "" if there was none.
+	ConfigFile string
+
+	// FilePath is the config file consulted, whether or not it exists.
+	// "config set" writes to it.
+	FilePath string
+
+	// FileErrors lists the config file lines that were ignored because they are invalid.
+	FileErrors []error
+
+	// Sources records where each setting came from, keyed by setting name.
+	Sources map[string]Source
+}
+
+// Default returns the configuration made of built-in defaults only.
+func Default() *Config {
+	cfg := &Config{Sources: map[string]Source{}}
+	for _, k := range Keys {
+		k.set(cfg, k.Default, SourceDefault)
+	}
+	return cfg
 }
 
-// LoadConfig loads configuration from flags and potentially config files (later).
-func LoadConfig() (*Config, error) {
-	cfg := &Config{}
+// Load builds the configuration from, in increasing order of precedence: built-in defaults,
+// the config file, OPEN_TEMPLATE_* environment variables and command-line flags.
+// args are the command-line arguments without the program name.
+func Load(args []string) (*Config, error) {
+	cfg := Default()
 
-	flags := flag.NewFlagSet("template-manager", flag.ContinueOnError)
+	flags := flag.NewFlagSet("open-template", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	set := setupFlags(flags, cfg)
+	if err := flags.Parse(args); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			return nil, err
+		}
+		cfg.Help = true
+	}
 
-	// Move flag setup to flag.go
-	SetupFlags(flags, cfg)
-	if err := flags.Parse(os.Args[1:]); err != nil && err != flag.ErrHelp {
+	// Config file: an explicitly named file must exist, the default one is optional.
+	path, explicit := set.configPath, set.configPath != ""
+	if !explicit {
+		path, explicit = os.LookupEnv("OPEN_TEMPLATE_CONFIG")
+	}
+	if !explicit {
+		var err error
+		if path, err = paths.ConfigFile(); err != nil {
+			return nil, err
+		}
+	}
+	cfg.FilePath = path
+	if err := cfg.loadFile(path, explicit); err != nil {
 		return nil, err
 	}
 
-	// Get non-flag arguments (commands)
-	args := flag.Args()
-	if len(args) > 0 {
-		command := args[0]
-		switch command {
-		case "auth", "sync", "status":
-			cfg.Command = command
-		default:
-			fmt.Printf("Unknown command: %s\n", command)
-			fmt.Println("Use '--help' to see available commands.")
-			os.Exit(1)
+	// Environment variables.
+	for _, k := range Keys {
+		raw, ok := os.LookupEnv(k.EnvVar())
+		if !ok {
+			continue
 		}
+		v, err := k.ParseString(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", k.EnvVar(), err)
+		}
+		k.set(cfg, v, SourceEnv)
 	}
 
-	// Handle help flag
-	if cfg.Help {
-		PrintHelp()              // Moved help printing here and updated to use flagset
-		return nil, flag.ErrHelp // Return error to signal help was shown, main can exit.
+	// Flags, in the order they were given.
+	for _, f := range set.values {
+		v, err := f.key.ParseString(f.raw)
+		if err != nil {
+			return nil, fmt.Errorf("--%s: %w", f.key.FlagName(), err)
+		}
+		f.key.set(cfg, v, SourceFlag)
 	}
 
+	if len(cfg.TemplateDirs) == 0 {
+		return nil, errors.New("template_dirs must name at least one template root")
+	}
+	for i, dir := range cfg.TemplateDirs {
+		cfg.TemplateDirs[i] = expandHome(dir)
+	}
+
+	if rest := flags.Args(); len(rest) > 0 {
+		cfg.Command = rest[0]
+		cfg.Args = rest[1:]
+	}
 	return cfg, nil
 }
+
+// loadFile applies the settings of the config file at path. Unknown keys are ignored
+// so older versions keep working with newer files. Invalid lines are skipped and recorded
+// in FileErrors; "config validate" reports both.
+func (cfg *Config) loadFile(path string, required bool) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	entries, errs := ParseFile(data)
+	for _, e := range entries {
+		k, ok := LookupKey(e.Key)
+		if !ok {
+			continue
+		}
+		v, err := k.Check(e.Value)
+		if err != nil {
+			errs = append(errs, &LineError{e.Line, err})
+			continue
+		}
+		k.set(cfg, v, SourceFile)
+	}
+	sortByLine(errs)
+	cfg.FileErrors = errs
+	cfg.ConfigFile = path
+	return nil
+}
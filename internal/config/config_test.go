@@ -0,0 +1,137 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// clearEnv unsets every OPEN_TEMPLATE_* variable for the duration of the test.
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range append([]string{"OPEN_TEMPLATE_CONFIG"}, keyEnvVars()...) {
+		if _, ok := os.LookupEnv(name); ok {
+			t.Setenv(name, "")
+			os.Unsetenv(name)
+		}
+	}
+}
+
+func keyEnvVars() []string {
+	var names []string
+	for _, k := range Keys {
+		names = append(names, k.EnvVar())
+	}
+	return names
+}
+
+func TestLoad(t *testing.T) {
+	const file = `template_dirs = ["/file"]
+depth = 2
+sync_remote = "upstream"
+ui = "sideways"   # invalid, skipped
+`
+	tests := []struct {
+		name        string
+		env         map[string]string
+		args        []string
+		wantDepth   int
+		wantDirs    []string
+		wantRemote  string
+		wantSources map[string]Source
+		wantCommand []string
+	}{
+		{
+			name:        "file over defaults",
+			wantDepth:   2,
+			wantDirs:    []string{"/file"},
+			wantRemote:  "upstream",
+			wantSources: map[string]Source{"depth": SourceFile, "ui": SourceDefault, "verbose": SourceDefault},
+		},
+		{
+			name:        "env over file",
+			env:         map[string]string{"OPEN_TEMPLATE_DEPTH": "3", "OPEN_TEMPLATE_TEMPLATE_DIRS": "/a" + string(os.PathListSeparator) + "/b"},
+			wantDepth:   3,
+			wantDirs:    []string{"/a", "/b"},
+			wantRemote:  "upstream",
+			wantSources: map[string]Source{"depth": SourceEnv, "template_dirs": SourceEnv, "sync_remote": SourceFile},
+		},
+		{
+			name:        "flags over env",
+			env:         map[string]string{"OPEN_TEMPLATE_DEPTH": "3"},
+			args:        []string{"--depth", "4", "--depth=5", "--sync-remote", "fork", "status", "--format", "json"},
+			wantDepth:   5,
+			wantDirs:    []string{"/file"},
+			wantRemote:  "fork",
+			wantSources: map[string]Source{"depth": SourceFlag, "sync_remote": SourceFlag},
+			wantCommand: []string{"status", "--format", "json"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(path, []byte(file), 0644); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("OPEN_TEMPLATE_CONFIG", path)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cfg, err := Load(tt.args)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if cfg.Depth != tt.wantDepth || !slices.Equal(cfg.TemplateDirs, tt.wantDirs) || cfg.SyncRemote != tt.wantRemote {
+				t.Errorf("depth, dirs, remote = %d, %q, %q; want %d, %q, %q",
+					cfg.Depth, cfg.TemplateDirs, cfg.SyncRemote, tt.wantDepth, tt.wantDirs, tt.wantRemote)
+			}
+			if cfg.UI != "auto" || len(cfg.FileErrors) != 1 {
+				t.Errorf("invalid ui line: UI = %q, FileErrors = %v; want the default and one error", cfg.UI, cfg.FileErrors)
+			}
+			for name, want := range tt.wantSources {
+				if got := cfg.Sources[name]; got != want {
+					t.Errorf("source of %s = %s, want %s", name, got, want)
+				}
+			}
+			if got := append([]string{cfg.Command}, cfg.Args...); tt.wantCommand != nil && !slices.Equal(got, tt.wantCommand) {
+				t.Errorf("command = %q, want %q", got, tt.wantCommand)
+			}
+			if cfg.ConfigFile != path {
+				t.Errorf("ConfigFile = %q, want %q", cfg.ConfigFile, path)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		args []string
+	}{
+		{"missing explicit config file", nil, []string{"--config", "/nonexistent/config.toml"}},
+		{"invalid env value", map[string]string{"OPEN_TEMPLATE_DEPTH": "deep"}, nil},
+		{"invalid flag value", nil, []string{"--ui", "sideways"}},
+		{"unknown flag", nil, []string{"--colour"}},
+		{"no template roots", map[string]string{"OPEN_TEMPLATE_TEMPLATE_DIRS": ""}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(path, nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("OPEN_TEMPLATE_CONFIG", path)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			if _, err := Load(tt.args); err == nil {
+				t.Error("Load succeeded, want an error")
+			}
+		})
+	}
+}
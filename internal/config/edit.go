@@ -0,0 +1,132 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Validate checks the config file at path and returns every problem found:
+// syntax errors, unknown keys, keys set twice and values of the wrong type.
+// Each problem is a *LineError.
+func Validate(path string) ([]error, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, errs := ParseFile(data)
+	seen := map[string]int{}
+	for _, e := range entries {
+		k, ok := LookupKey(e.Key)
+		if !ok {
+			errs = append(errs, &LineError{e.Line, fmt.Errorf("unknown key %q; the setting is ignored", e.Key)})
+			continue
+		}
+		if line, dup := seen[e.Key]; dup {
+			errs = append(errs, &LineError{e.Line, fmt.Errorf("%s is already set on line %d", e.Key, line)})
+		}
+		seen[e.Key] = e.Line
+		if _, err := k.Check(e.Value); err != nil {
+			errs = append(errs, &LineError{e.Line, err})
+		}
+	}
+
+	sortByLine(errs)
+	return errs, nil
+}
+
+// sortByLine orders line errors by line number.
+func sortByLine(errs []error) {
+	line := func(err error) int {
+		var le *LineError
+		if errors.As(err, &le) {
+			return le.Line
+		}
+		return 0
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return line(errs[i]) < line(errs[j]) })
+}
+
+// SetInFile writes "key = value" to the config file at path, replacing an existing line
+// for the key and keeping every other line, comments included. The file is created if needed.
+func SetInFile(path string, k *Key, value any) error {
+	if _, err := k.Check(value); err != nil {
+		return err
+	}
+	line := k.Name + " = " + FormatValue(value)
+
+	lines, err := readLines(path)
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i, l := range lines {
+		if lineKey(l) == k.Name {
+			if replaced {
+				lines[i] = "# " + l
+				continue
+			}
+			lines[i] = line
+			replaced = true
+		}
+	}
+	if !replaced {
+		lines = append(lines, line)
+	}
+	return writeLines(path, lines)
+}
+
+// UnsetInFile removes the key from the config file at path and reports whether it was set.
+func UnsetInFile(path string, k *Key) (bool, error) {
+	lines, err := readLines(path)
+	if err != nil {
+		return false, err
+	}
+	kept := lines[:0]
+	for _, l := range lines {
+		if lineKey(l) != k.Name {
+			kept = append(kept, l)
+		}
+	}
+	if len(kept) == len(lines) {
+		return false, nil
+	}
+	return true, writeLines(path, kept)
+}
+
+// lineKey returns the key set on a config file line, "" for blank and comment lines.
+func lineKey(line string) string {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "#") {
+		return ""
+	}
+	key, _, ok := strings.Cut(line, "=")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(key)
+}
+
+// readLines reads the config file as lines; a missing file has none.
+func readLines(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimRight(string(data), "\n"), "\n"), nil
+}
+
+// writeLines replaces the config file with the given lines.
+func writeLines(path string, lines []string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
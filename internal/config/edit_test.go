@@ -0,0 +1,118 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	data := `depth = 2
+colour = "red"
+depth = "deep"
+[table]
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	errs, err := Validate(path)
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	want := []string{
+		`line 2: unknown key "colour"; the setting is ignored`,
+		"line 3: depth is already set on line 1",
+		`line 3: depth must be of type integer, got "deep"`,
+		"line 4: tables are not supported; use top-level keys",
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("Validate = %v, want %d problems", errs, len(want))
+	}
+	for i, err := range errs {
+		if err.Error() != want[i] {
+			t.Errorf("problem %d = %q, want %q", i, err, want[i])
+		}
+	}
+}
+
+func TestSetAndUnsetInFile(t *testing.T) {
+	depth, _ := LookupKey("depth")
+	dirs, _ := LookupKey("template_dirs")
+	tests := []struct {
+		name   string
+		before string
+		edit   func(path string) error
+		after  string
+	}{
+		{
+			name:  "set creates the file",
+			edit:  func(path string) error { return SetInFile(path, depth, 2) },
+			after: "depth = 2\n",
+		},
+		{
+			name:   "set replaces the line and keeps comments",
+			before: "# roots\ntemplate_dirs = [\"/a\"]\ndepth = 1\n",
+			edit:   func(path string) error { return SetInFile(path, dirs, []string{"/b", "/c"}) },
+			after:  "# roots\ntemplate_dirs = [\"/b\", \"/c\"]\ndepth = 1\n",
+		},
+		{
+			name:   "set comments out duplicates",
+			before: "depth = 1\ndepth = 3\n",
+			edit:   func(path string) error { return SetInFile(path, depth, 2) },
+			after:  "depth = 2\n# depth = 3\n",
+		},
+		{
+			name:   "set appends",
+			before: "verbose = true\n",
+			edit:   func(path string) error { return SetInFile(path, depth, -1) },
+			after:  "verbose = true\ndepth = -1\n",
+		},
+		{
+			name:   "unset",
+			before: "depth = 1\n# depth = 3\nverbose = true\n",
+			edit: func(path string) error {
+				_, err := UnsetInFile(path, depth)
+				return err
+			},
+			after: "# depth = 3\nverbose = true\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "open-template", "config.toml")
+			if tt.before != "" {
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(tt.before), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := tt.edit(path); err != nil {
+				t.Fatalf("edit: %v", err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.after {
+				t.Errorf("file = %q, want %q", data, tt.after)
+			}
+		})
+	}
+}
+
+func TestSetInFileRejectsInvalidValues(t *testing.T) {
+	ui, _ := LookupKey("ui")
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := SetInFile(path, ui, "sideways"); err == nil {
+		t.Error("SetInFile accepted ui = sideways")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("config file written for an invalid value: %v", err)
+	}
+	if removed, err := UnsetInFile(path, ui); removed || err != nil {
+		t.Errorf("UnsetInFile of a missing file = %v, %v; want false, nil", removed, err)
+	}
+}
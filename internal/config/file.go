@@ -0,0 +1,158 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The config file uses a small subset of TOML: one "key = value" per line, "#" comments,
+// and values that are quoted strings, integers, booleans or single-line arrays of strings.
+//
+//	# ~/.config/open-template/config.toml
+//	template_dirs = ["~/templates", "/srv/shared-templates"]
+//	depth = 2
+
+// Entry is one "key = value" line of the config file.
+type Entry struct {
+	Key   string
+	Value any // string, int, bool or []string
+	Line  int
+}
+
+// LineError is a problem on a specific line of the config file.
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// ParseFile parses the config file contents. It returns every entry that parsed
+// and an error for every line that did not.
+func ParseFile(data []byte) ([]Entry, []error) {
+	var entries []Entry
+	var errs []error
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			errs = append(errs, &LineError{i + 1, errors.New("tables are not supported; use top-level keys")})
+			continue
+		}
+
+		key, raw, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			errs = append(errs, &LineError{i + 1, fmt.Errorf("expected \"key = value\", got %q", line)})
+			continue
+		}
+
+		value, err := parseValue(strings.TrimSpace(raw))
+		if err != nil {
+			errs = append(errs, &LineError{i + 1, fmt.Errorf("%s: %w", key, err)})
+			continue
+		}
+		entries = append(entries, Entry{Key: key, Value: value, Line: i + 1})
+	}
+	return entries, errs
+}
+
+// parseValue parses a value followed by an optional comment.
+func parseValue(s string) (any, error) {
+	value, rest, err := scanValue(s)
+	if err != nil {
+		return nil, err
+	}
+	rest = strings.TrimSpace(rest)
+	if rest != "" && !strings.HasPrefix(rest, "#") {
+		return nil, fmt.Errorf("unexpected %q after value", rest)
+	}
+	return value, nil
+}
+
+// scanValue reads one value from the start of s and returns the unread remainder.
+func scanValue(s string) (any, string, error) {
+	switch {
+	case s == "":
+		return nil, "", errors.New("missing value")
+	case s[0] == '"' || s[0] == '\'':
+		return scanString(s)
+	case s[0] == '[':
+		return scanArray(s)
+	}
+
+	word, rest := s, ""
+	if i := strings.IndexAny(s, " \t#,]"); i >= 0 {
+		word, rest = s[:i], s[i:]
+	}
+	switch word {
+	case "true":
+		return true, rest, nil
+	case "false":
+		return false, rest, nil
+	}
+	if n, err := strconv.Atoi(word); err == nil {
+		return n, rest, nil
+	}
+	return nil, "", fmt.Errorf("invalid value %q (quote strings)", word)
+}
+
+// scanString reads a basic ("...") or literal ('...') string.
+func scanString(s string) (any, string, error) {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quote == '"':
+			i++
+		case s[i] == quote:
+			if quote == '\'' {
+				return s[1:i], s[i+1:], nil
+			}
+			str, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return nil, "", fmt.Errorf("invalid string %s", s[:i+1])
+			}
+			return str, s[i+1:], nil
+		}
+	}
+	return nil, "", errors.New("unterminated string")
+}
+
+// scanArray reads a single-line array of strings.
+func scanArray(s string) (any, string, error) {
+	list := []string{}
+	rest := strings.TrimSpace(s[1:])
+	for {
+		if strings.HasPrefix(rest, "]") {
+			return list, rest[1:], nil
+		}
+		if rest == "" {
+			return nil, "", errors.New("unterminated array")
+		}
+		v, r, err := scanValue(rest)
+		if err != nil {
+			return nil, "", err
+		}
+		str, ok := v.(string)
+		if !ok {
+			return nil, "", fmt.Errorf("array elements must be strings, got %v", v)
+		}
+		list = append(list, str)
+		rest = strings.TrimSpace(r)
+		if strings.HasPrefix(rest, ",") {
+			rest = strings.TrimSpace(rest[1:])
+		} else if !strings.HasPrefix(rest, "]") {
+			return nil, "", errors.New("expected \",\" or \"]\" in array")
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFile(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want any // parsed value, nil when the line is an error
+	}{
+		{"basic string", `k = "a \"b\""`, `a "b"`},
+		{"literal string", `k = 'C:\dir'`, `C:\dir`},
+		{"integer", "k = -1", -1},
+		{"boolean", "k = true", true},
+		{"array", `k = ["a", 'b',]`, []string{"a", "b"}},
+		{"empty array", "k = []", []string{}},
+		{"comment after value", `k = "x" # note`, "x"},
+		{"hash in string", `k = "#x"`, "#x"},
+		{"bare word", "k = origin", nil},
+		{"missing value", "k =", nil},
+		{"missing equals", "k", nil},
+		{"table", "[section]", nil},
+		{"unterminated string", `k = "x`, nil},
+		{"unterminated array", `k = ["x"`, nil},
+		{"array of integers", "k = [1]", nil},
+		{"trailing garbage", `k = "x" y`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, errs := ParseFile([]byte("# comment\n\n" + tt.line + "\n"))
+			if tt.want == nil {
+				if len(entries) != 0 || len(errs) != 1 {
+					t.Fatalf("ParseFile(%q) = %v, %v; want one error", tt.line, entries, errs)
+				}
+				if le, ok := errs[0].(*LineError); !ok || le.Line != 3 {
+					t.Errorf("error %v is not on line 3", errs[0])
+				}
+				return
+			}
+			if len(errs) != 0 || len(entries) != 1 {
+				t.Fatalf("ParseFile(%q) = %v, %v; want one entry", tt.line, entries, errs)
+			}
+			if e := entries[0]; e.Key != "k" || e.Line != 3 || !reflect.DeepEqual(e.Value, tt.want) {
+				t.Errorf("entry = %+v, want k = %#v on line 3", e, tt.want)
+			}
+		})
+	}
+}
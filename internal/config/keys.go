@@ -0,0 +1,245 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DefaultTemplateDir is the template root used when none is configured.
+const DefaultTemplateDir = "/Users/ayushkumar/programming/templates"
+
+// Source tells where the value of a setting came from.
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceFile    Source = "file"
+	SourceEnv     Source = "env"
+	SourceFlag    Source = "flag"
+)
+
+// Kind is the type of a setting's value.
+type Kind int
+
+const (
+	KindString Kind = iota
+	KindInt
+	KindBool
+	KindPaths // list of paths; a path list ("a:b") in env vars and flags
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindInt:
+		return "integer"
+	case KindBool:
+		return "boolean"
+	case KindPaths:
+		return "list of paths"
+	}
+	return "string"
+}
+
+// Key describes one setting that can come from the config file, the environment or a flag.
+type Key struct {
+	Name    string // name in the config file, e.g. "template_dirs"
+	Kind    Kind
+	Help    string
+	Default any
+	Values  []string // allowed values of a string setting, if restricted
+
+	// field returns a pointer to the setting inside cfg.
+	field func(cfg *Config) any
+}
+
+// Keys lists every setting, in the order they are documented.
+var Keys = []*Key{
+	{
+		Name:    "template_dirs",
+		Kind:    KindPaths,
+		Help:    "Template roots, searched in order",
+		Default: []string{DefaultTemplateDir},
+		field:   func(cfg *Config) any { return &cfg.TemplateDirs },
+	},
+	{
+		Name:    "depth",
+		Kind:    KindInt,
+		Help:    "Max depth for file tree (-1 for unlimited)",
+		Default: 1,
+		field:   func(cfg *Config) any { return &cfg.Depth },
+	},
+	{
+		Name:    "verbose",
+		Kind:    KindBool,
+		Help:    "Enable verbose logging",
+		Default: false,
+		field:   func(cfg *Config) any { return &cfg.Verbose },
+	},
+	{
+		Name:    "sync_remote",
+		Kind:    KindString,
+		Help:    "Git remote the template roots sync with",
+		Default: "origin",
+		field:   func(cfg *Config) any { return &cfg.SyncRemote },
+	},
+	{
+		Name:    "ui",
+		Kind:    KindString,
+		Help:    "Interface without a command: auto (TUI on a terminal, prompts otherwise), tui or plain",
+		Default: "auto",
+		Values:  []string{"auto", "tui", "plain"},
+		field:   func(cfg *Config) any { return &cfg.UI },
+	},
+	{
+		Name:    "on_cancel",
+		Kind:    KindString,
+		Help:    "What an interrupted generation leaves behind: rollback (remove what it wrote) or keep",
+		Default: "rollback",
+		Values:  []string{"rollback", "keep"},
+		field:   func(cfg *Config) any { return &cfg.OnCancel },
+	},
+	{
+		Name:    "log_level",
+		Kind:    KindString,
+		Help:    "Least severe log messages written: debug, info, warn or error (--verbose means debug)",
+		Default: "warn",
+		Values:  []string{"debug", "info", "warn", "error"},
+		field:   func(cfg *Config) any { return &cfg.LogLevel },
+	},
+}
+
+// LookupKey returns the setting with the given name.
+func LookupKey(name string) (*Key, bool) {
+	for _, k := range Keys {
+		if k.Name == name {
+			return k, true
+		}
+	}
+	return nil, false
+}
+
+// EnvVar is the environment variable overriding the setting, e.g. OPEN_TEMPLATE_DEPTH.
+func (k *Key) EnvVar() string {
+	return "OPEN_TEMPLATE_" + strings.ToUpper(k.Name)
+}
+
+// FlagName is the command-line flag overriding the setting, e.g. --template-dirs.
+func (k *Key) FlagName() string {
+	return strings.ReplaceAll(k.Name, "_", "-")
+}
+
+// ParseString converts the plain string form used by env vars and flags into a value of the key's kind.
+func (k *Key) ParseString(s string) (any, error) {
+	switch k.Kind {
+	case KindInt:
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not an integer", k.Name, s)
+		}
+		return n, nil
+	case KindBool:
+		b, err := strconv.ParseBool(strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not a boolean", k.Name, s)
+		}
+		return b, nil
+	case KindPaths:
+		return filepath.SplitList(s), nil
+	}
+	return k.checkValue(s)
+}
+
+// checkValue verifies a string setting against its allowed values.
+func (k *Key) checkValue(s string) (any, error) {
+	if len(k.Values) > 0 && !slices.Contains(k.Values, s) {
+		return nil, fmt.Errorf("%s must be one of %s, got %q", k.Name, strings.Join(k.Values, ", "), s)
+	}
+	return s, nil
+}
+
+// Check verifies that a value decoded from the config file has the key's kind.
+func (k *Key) Check(v any) (any, error) {
+	switch k.Kind {
+	case KindInt:
+		if n, ok := v.(int); ok {
+			return n, nil
+		}
+	case KindBool:
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	case KindPaths:
+		switch v := v.(type) {
+		case []string:
+			return v, nil
+		case string:
+			return []string{v}, nil
+		}
+	case KindString:
+		if s, ok := v.(string); ok {
+			return k.checkValue(s)
+		}
+	}
+	return nil, fmt.Errorf("%s must be of type %s, got %s", k.Name, k.Kind, FormatValue(v))
+}
+
+// Get returns the current value of the setting in cfg.
+func (k *Key) Get(cfg *Config) any {
+	switch p := k.field(cfg).(type) {
+	case *string:
+		return *p
+	case *int:
+		return *p
+	case *bool:
+		return *p
+	case *[]string:
+		return *p
+	}
+	return nil
+}
+
+// set stores v, which must already have the key's kind, and records its source.
+func (k *Key) set(cfg *Config, v any, src Source) {
+	switch p := k.field(cfg).(type) {
+	case *string:
+		*p = v.(string)
+	case *int:
+		*p = v.(int)
+	case *bool:
+		*p = v.(bool)
+	case *[]string:
+		*p = append([]string(nil), v.([]string)...)
+	}
+	cfg.Sources[k.Name] = src
+}
+
+// FormatValue renders a setting value the way it is written in the config file.
+func FormatValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case []string:
+		quoted := make([]string, len(v))
+		for i, s := range v {
+			quoted[i] = strconv.Quote(s)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	}
+	return fmt.Sprint(v)
+}
+
+// expandHome replaces a leading "~" in path with the user's home directory.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
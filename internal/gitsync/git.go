@@ -0,0 +1,65 @@
+package gitsync
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// git runs a git command in dir and returns its standard output without the trailing newline.
+// On failure the error carries git's own message.
+func git(dir string, args ...string) (string, error) {
+	return gitConfig(dir, nil, args...)
+}
+
+// gitConfig is like git, setting each "key=value" of config for this command only.
+// The settings are passed in the environment rather than as "-c" options, which any
+// user could read from the process list.
+func gitConfig(dir string, config []string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	if len(config) > 0 {
+		cmd.Env = append(os.Environ(), configEnv(os.Getenv("GIT_CONFIG_COUNT"), config)...)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	// config may carry credentials, so only the git arguments are logged.
+	start := time.Now()
+	err := cmd.Run()
+	slog.Debug("ran git", "dir", dir, "args", args, "duration", time.Since(start), "error", err)
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
+
+// configEnv returns the GIT_CONFIG_* variables setting each "key=value" of config, after
+// the count settings already in the environment.
+func configEnv(count string, config []string) []string {
+	n, _ := strconv.Atoi(count)
+	var env []string
+	for _, c := range config {
+		key, value, _ := strings.Cut(c, "=")
+		env = append(env,
+			fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", n, key),
+			fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", n, value))
+		n++
+	}
+	return append(env, fmt.Sprintf("GIT_CONFIG_COUNT=%d", n))
+}
+
+// refExists reports whether ref names an existing object.
+func refExists(dir, ref string) bool {
+	_, err := git(dir, "rev-parse", "--verify", "--quiet", ref)
+	return err == nil
+}
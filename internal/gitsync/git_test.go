@@ -0,0 +1,60 @@
+package gitsync
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestConfigEnv(t *testing.T) {
+	tests := []struct {
+		name   string
+		count  string
+		config []string
+		want   []string
+	}{
+		{
+			name:   "empty environment",
+			config: []string{"http.extraHeader=Authorization: Bearer s3cr=t"},
+			want: []string{
+				"GIT_CONFIG_KEY_0=http.extraHeader",
+				"GIT_CONFIG_VALUE_0=Authorization: Bearer s3cr=t",
+				"GIT_CONFIG_COUNT=1",
+			},
+		},
+		{
+			name:   "after settings in the environment",
+			count:  "2",
+			config: []string{"a.b=1", "c.d=2"},
+			want: []string{
+				"GIT_CONFIG_KEY_2=a.b", "GIT_CONFIG_VALUE_2=1",
+				"GIT_CONFIG_KEY_3=c.d", "GIT_CONFIG_VALUE_3=2",
+				"GIT_CONFIG_COUNT=4",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := configEnv(tt.count, tt.config); !slices.Equal(got, tt.want) {
+				t.Errorf("configEnv(%q, %q) = %q, want %q", tt.count, tt.config, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitConfig(t *testing.T) {
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "user.email")
+	t.Setenv("GIT_CONFIG_VALUE_0", "env@example.com")
+
+	dir := t.TempDir()
+	run(t, dir, "init", "--quiet")
+	for key, want := range map[string]string{"user.name": "from-config", "user.email": "env@example.com"} {
+		got, err := gitConfig(dir, []string{"user.name=from-config"}, "config", key)
+		if err != nil {
+			t.Fatalf("git config %s: %v", key, err)
+		}
+		if got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
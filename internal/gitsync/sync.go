@@ -0,0 +1,248 @@
+package gitsync
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRemote is the remote synced with when none is configured.
+const DefaultRemote = "origin"
+
+// ErrDiverged is returned when local and remote history both have commits the other lacks.
+// Sync never merges on its own; the user resolves divergence with git.
+var ErrDiverged = errors.New("local and remote templates have diverged")
+
+// Options controls a sync.
+type Options struct {
+	Remote  string // remote name, DefaultRemote if empty
+	Push    bool   // commit local edits and push local commits to the remote
+	Message string // commit message for local edits when pushing
+
+	// Token returns the bearer token for the remote URL, or "" to let git authenticate itself.
+	Token func(remoteURL string) string
+}
+
+// Result describes what a sync did.
+type Result struct {
+	Branch        string
+	Remote        string
+	Ahead         int // local commits missing on the remote, after the sync
+	Behind        int // remote commits missing locally, before the sync
+	Committed     bool
+	FastForwarded bool
+	Pushed        bool
+}
+
+// Status is the sync state of a template root relative to its remote.
+type Status struct {
+	Branch string
+	Remote string
+	Ahead  int
+	Behind int
+	Dirty  []string // paths with uncommitted changes, relative to the root
+}
+
+// Sync fetches from the remote, fast-forwards the template root in dir and, if asked,
+// pushes local changes. Divergent histories are reported as ErrDiverged.
+func Sync(dir string, opts Options) (*Result, error) {
+	if opts.Remote == "" {
+		opts.Remote = DefaultRemote
+	}
+	branch, err := checkRepo(dir, opts.Remote)
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{Branch: branch, Remote: opts.Remote}
+
+	var config []string
+	if opts.Token != nil {
+		url, err := git(dir, "remote", "get-url", opts.Remote)
+		if err != nil {
+			return nil, err
+		}
+		if token := opts.Token(url); token != "" {
+			config = append(config, "http.extraHeader=Authorization: Bearer "+token)
+		}
+	}
+
+	if _, err := gitConfig(dir, config, "fetch", "--quiet", opts.Remote); err != nil {
+		return nil, err
+	}
+
+	if opts.Push {
+		dirty, err := dirtyPaths(dir)
+		if err != nil {
+			return nil, err
+		}
+		if len(dirty) > 0 {
+			msg := opts.Message
+			if msg == "" {
+				msg = "Update templates"
+			}
+			// The root may be a subdirectory of a larger repository; leave the rest of it alone.
+			if _, err := git(dir, "add", "--all", "--", "."); err != nil {
+				return nil, err
+			}
+			if _, err := git(dir, "commit", "--quiet", "-m", msg, "--", "."); err != nil {
+				return nil, err
+			}
+			res.Committed = true
+		}
+	}
+
+	ahead, behind, err := counts(dir, opts.Remote, branch)
+	if err != nil {
+		return nil, err
+	}
+	res.Ahead, res.Behind = ahead, behind
+
+	if ahead > 0 && behind > 0 {
+		slog.Warn("template root diverged from remote", "dir", dir, "ahead", ahead, "behind", behind)
+		return res, fmt.Errorf("%w: %s has %d local and %s/%s has %d remote commits; rebase or merge them with git in %s",
+			ErrDiverged, branch, ahead, opts.Remote, branch, behind, dir)
+	}
+
+	if behind > 0 {
+		if _, err := git(dir, "merge", "--ff-only", "--quiet", opts.Remote+"/"+branch); err != nil {
+			return res, err
+		}
+		res.FastForwarded = true
+	}
+
+	if ahead > 0 && opts.Push {
+		if _, err := gitConfig(dir, config, "push", "--quiet", "--set-upstream", opts.Remote, branch); err != nil {
+			return res, err
+		}
+		res.Pushed = true
+		res.Ahead = 0
+	}
+	slog.Info("synced template root", "dir", dir, "remote", opts.Remote, "branch", branch,
+		"pulled", res.Behind, "committed", res.Committed, "pushed", res.Pushed)
+	return res, nil
+}
+
+// State reports how the template root in dir compares to the remote as of the last fetch.
+// It does not contact the remote.
+func State(dir, remote string) (*Status, error) {
+	if remote == "" {
+		remote = DefaultRemote
+	}
+	branch, err := checkRepo(dir, remote)
+	if err != nil {
+		return nil, err
+	}
+	st := &Status{Branch: branch, Remote: remote}
+	if st.Ahead, st.Behind, err = counts(dir, remote, branch); err != nil {
+		return nil, err
+	}
+	if st.Dirty, err = dirtyPaths(dir); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// checkRepo verifies dir is a git working copy with the remote configured and returns the current branch.
+func checkRepo(dir, remote string) (string, error) {
+	if _, err := git(dir, "rev-parse", "--is-inside-work-tree"); err != nil {
+		return "", fmt.Errorf("%s is not a git working copy; run \"git init\" there and add a remote to sync it", dir)
+	}
+	if _, err := git(dir, "remote", "get-url", remote); err != nil {
+		return "", fmt.Errorf("remote %q is not configured in %s; add it with \"git remote add %s <url>\"", remote, dir, remote)
+	}
+	branch, err := git(dir, "symbolic-ref", "--short", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("%s is not on a branch: %w", dir, err)
+	}
+	return branch, nil
+}
+
+// counts returns the number of commits only on the local branch and only on the remote branch.
+func counts(dir, remote, branch string) (ahead, behind int, err error) {
+	remoteRef := "refs/remotes/" + remote + "/" + branch
+	hasLocal := refExists(dir, "HEAD")
+	hasRemote := refExists(dir, remoteRef)
+
+	switch {
+	case !hasLocal && !hasRemote:
+		return 0, 0, nil
+	case !hasRemote:
+		// The remote branch does not exist yet, e.g. a fresh bare repository.
+		out, err := git(dir, "rev-list", "--count", "HEAD")
+		if err != nil {
+			return 0, 0, err
+		}
+		ahead, err = strconv.Atoi(out)
+		return ahead, 0, err
+	case !hasLocal:
+		out, err := git(dir, "rev-list", "--count", remoteRef)
+		if err != nil {
+			return 0, 0, err
+		}
+		behind, err = strconv.Atoi(out)
+		return 0, behind, err
+	}
+
+	out, err := git(dir, "rev-list", "--left-right", "--count", "HEAD..."+remoteRef)
+	if err != nil {
+		return 0, 0, err
+	}
+	fields := strings.Fields(out)
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected git rev-list output %q", out)
+	}
+	if ahead, err = strconv.Atoi(fields[0]); err != nil {
+		return 0, 0, err
+	}
+	behind, err = strconv.Atoi(fields[1])
+	return ahead, behind, err
+}
+
+// dirtyPaths lists paths below dir with uncommitted changes, including untracked files,
+// relative to dir.
+func dirtyPaths(dir string) ([]string, error) {
+	prefix, err := git(dir, "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, err
+	}
+	out, err := git(dir, "status", "--porcelain", "-z", "--untracked-files=all", "--", ".")
+	if err != nil {
+		return nil, err
+	}
+	// Each entry is "XY path", relative to the repository root, and a rename or copy is
+	// followed by its source path as an entry of its own.
+	var paths []string
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		if entry[0] == 'R' || entry[0] == 'C' {
+			i++
+		}
+		paths = append(paths, strings.TrimPrefix(entry[3:], prefix))
+	}
+	return paths, nil
+}
+
+// LastFetch returns when the template root in dir was last fetched from its remote.
+// The zero time means it has never been fetched.
+func LastFetch(dir string) (time.Time, error) {
+	path, err := git(dir, "rev-parse", "--path-format=absolute", "--git-path", "FETCH_HEAD")
+	if err != nil {
+		return time.Time{}, err
+	}
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
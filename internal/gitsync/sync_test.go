@@ -0,0 +1,156 @@
+package gitsync
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// newRemote creates a bare repository in a temporary directory and returns its file:// URL.
+func newRemote(t *testing.T) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "remote.git")
+	run(t, "", "init", "--quiet", "--bare", "--initial-branch=main", dir)
+	return "file://" + filepath.ToSlash(dir)
+}
+
+// newClone creates a working copy on branch main with url as its origin.
+func newClone(t *testing.T, url string) string {
+	t.Helper()
+	dir := t.TempDir()
+	run(t, dir, "init", "--quiet", "--initial-branch=main")
+	run(t, dir, "remote", "add", "origin", url)
+	return dir
+}
+
+// run runs git in dir, failing the test on error.
+func run(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	if dir != "" {
+		args = append([]string{"-C", dir}, args...)
+	}
+	out, err := git(".", args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMain(m *testing.M) {
+	// Commits need an identity, and the user's git configuration must not leak in.
+	for k, v := range map[string]string{
+		"GIT_CONFIG_GLOBAL":   os.DevNull,
+		"GIT_CONFIG_NOSYSTEM": "1",
+		"GIT_AUTHOR_NAME":     "test",
+		"GIT_AUTHOR_EMAIL":    "test@example.com",
+		"GIT_COMMITTER_NAME":  "test",
+		"GIT_COMMITTER_EMAIL": "test@example.com",
+	} {
+		os.Setenv(k, v)
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+func TestSync(t *testing.T) {
+	tests := []struct {
+		name string
+		root string // template root within the working copy
+	}{
+		{"repository root", "."},
+		{"subdirectory", "templates"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := newRemote(t)
+			a, b := newClone(t, url), newClone(t, url)
+			rootA, rootB := filepath.Join(a, tt.root), filepath.Join(b, tt.root)
+
+			// Push a first template from a.
+			writeFile(t, filepath.Join(rootA, "go-service", "main.go"), "package main\n")
+			st, err := State(rootA, "")
+			if err != nil {
+				t.Fatalf("State: %v", err)
+			}
+			if want := []string{"go-service/main.go"}; !slices.Equal(st.Dirty, want) {
+				t.Errorf("Dirty = %q, want %q", st.Dirty, want)
+			}
+			res, err := Sync(rootA, Options{Push: true})
+			if err != nil {
+				t.Fatalf("push: %v", err)
+			}
+			if !res.Committed || !res.Pushed || res.Ahead != 0 {
+				t.Errorf("push result = %+v, want committed and pushed", res)
+			}
+
+			// b fast-forwards to it.
+			if err := os.MkdirAll(rootB, 0755); err != nil {
+				t.Fatal(err)
+			}
+			res, err = Sync(rootB, Options{})
+			if err != nil {
+				t.Fatalf("pull: %v", err)
+			}
+			if !res.FastForwarded || res.Behind != 1 {
+				t.Errorf("pull result = %+v, want fast-forward of 1 commit", res)
+			}
+			if _, err := os.Stat(filepath.Join(rootB, "go-service", "main.go")); err != nil {
+				t.Errorf("template not pulled: %v", err)
+			}
+
+			// Both sides commit: the sync reports divergence instead of merging.
+			writeFile(t, filepath.Join(rootA, "go-service", "a.txt"), "a")
+			if _, err := Sync(rootA, Options{Push: true}); err != nil {
+				t.Fatalf("push a: %v", err)
+			}
+			writeFile(t, filepath.Join(rootB, "go-service", "b.txt"), "b")
+			res, err = Sync(rootB, Options{Push: true})
+			if !errors.Is(err, ErrDiverged) {
+				t.Fatalf("Sync of diverged root = %v, want ErrDiverged", err)
+			}
+			if res.Ahead != 1 || res.Behind != 1 || res.Pushed {
+				t.Errorf("diverged result = %+v, want 1 ahead, 1 behind, not pushed", res)
+			}
+		})
+	}
+}
+
+func TestSyncLeavesRestOfRepository(t *testing.T) {
+	url := newRemote(t)
+	repo := newClone(t, url)
+	root := filepath.Join(repo, "templates")
+	writeFile(t, filepath.Join(repo, "notes.txt"), "not a template\n")
+	writeFile(t, filepath.Join(root, "svc", "README.md"), "# svc\n")
+
+	st, err := State(root, "")
+	if err != nil {
+		t.Fatalf("State: %v", err)
+	}
+	if want := []string{"svc/README.md"}; !slices.Equal(st.Dirty, want) {
+		t.Errorf("Dirty = %q, want %q", st.Dirty, want)
+	}
+
+	if _, err := Sync(root, Options{Push: true, Message: "Add svc"}); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if files := run(t, repo, "ls-tree", "-r", "--name-only", "HEAD"); files != "templates/svc/README.md" {
+		t.Errorf("committed files = %q, want only the template", files)
+	}
+	if status := run(t, repo, "status", "--porcelain"); status != "?? notes.txt" {
+		t.Errorf("status after sync = %q, want notes.txt left untracked", status)
+	}
+}
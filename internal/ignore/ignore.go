@@ -0,0 +1,167 @@
+package ignore
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// rule is a single compiled .gitignore pattern.
+type rule struct {
+	base    string // slash-separated directory of the .gitignore, "" for the root
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// Matcher decides whether paths are ignored according to a set of .gitignore files.
+// Paths are slash-separated and relative to the root the matcher was created for.
+type Matcher struct {
+	rules []rule
+}
+
+// New returns an empty matcher that ignores nothing.
+func New() *Matcher {
+	return &Matcher{}
+}
+
+// Always reports whether a directory entry named name is ignored whatever the .gitignore
+// files say: .git, a repository's directory or, in a worktree or submodule, the file
+// pointing to it. Such entries are never part of a template nor of a generated project.
+func Always(name string) bool {
+	return name == ".git"
+}
+
+// AddFile adds the patterns of the .gitignore file in dir, relative to the matcher root.
+// A missing file is not an error.
+func (m *Matcher) AddFile(root, dir string) error {
+	f, err := os.Open(filepath.Join(root, dir, ".gitignore"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return m.Add(filepath.ToSlash(dir), f)
+}
+
+// Add adds the patterns read from r, which apply to paths below base.
+func (m *Matcher) Add(base string, r io.Reader) error {
+	if base == "." {
+		base = ""
+	}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if ru, ok := compile(base, scanner.Text()); ok {
+			m.rules = append(m.rules, ru)
+		}
+	}
+	return scanner.Err()
+}
+
+// Match reports whether the path is ignored. The last matching pattern wins,
+// so a later "!pattern" re-includes a path excluded by an earlier one.
+func (m *Matcher) Match(rel string, isDir bool) bool {
+	rel = filepath.ToSlash(rel)
+	ignored := false
+	for _, ru := range m.rules {
+		if ru.dirOnly && !isDir {
+			continue
+		}
+		p := rel
+		if ru.base != "" {
+			if !strings.HasPrefix(rel, ru.base+"/") {
+				continue
+			}
+			p = strings.TrimPrefix(rel, ru.base+"/")
+		}
+		if ru.re.MatchString(p) {
+			ignored = !ru.negate
+		}
+	}
+	return ignored
+}
+
+// compile turns one line of a .gitignore file into a rule.
+func compile(base, line string) (rule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule{}, false
+	}
+
+	ru := rule{base: base}
+	if strings.HasPrefix(line, "!") {
+		ru.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		ru.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+
+	// A pattern containing a slash is anchored to the .gitignore directory.
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	if line == "" {
+		return rule{}, false
+	}
+
+	var sb strings.Builder
+	sb.WriteString("^")
+	if !anchored {
+		sb.WriteString("(?:.*/)?")
+	}
+	sb.WriteString(globToRegexp(line))
+	sb.WriteString("$")
+
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return rule{}, false
+	}
+	ru.re = re
+	return ru, true
+}
+
+// globToRegexp translates gitignore glob syntax, including "**", into a regular expression.
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			sb.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,66 @@
+package ignore
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns map[string]string // .gitignore contents by directory, "" for the root
+		path     string
+		isDir    bool
+		want     bool
+	}{
+		{"plain name anywhere", map[string]string{"": "debug.log"}, "a/b/debug.log", false, true},
+		{"plain name other file", map[string]string{"": "debug.log"}, "a/b/other.log", false, false},
+		{"star", map[string]string{"": "*.log"}, "logs/x.log", false, true},
+		{"star stays in segment", map[string]string{"": "a*b"}, "a/b", false, false},
+		{"question mark", map[string]string{"": "?.txt"}, "x.txt", false, true},
+		{"question mark one char", map[string]string{"": "?.txt"}, "xy.txt", false, false},
+		{"class", map[string]string{"": "file[0-9]"}, "file7", false, true},
+		{"negated class", map[string]string{"": "file[!0-9]"}, "file7", false, false},
+		{"anchored", map[string]string{"": "/build"}, "build", true, true},
+		{"anchored not nested", map[string]string{"": "/build"}, "src/build", true, false},
+		{"slash anchors", map[string]string{"": "docs/*.md"}, "docs/a.md", false, true},
+		{"slash anchors not nested", map[string]string{"": "docs/*.md"}, "x/docs/a.md", false, false},
+		{"dir only matches dir", map[string]string{"": "out/"}, "out", true, true},
+		{"dir only skips file", map[string]string{"": "out/"}, "out", false, false},
+		{"leading double star", map[string]string{"": "**/cache"}, "a/b/cache", true, true},
+		{"middle double star", map[string]string{"": "a/**/z"}, "a/b/c/z", false, true},
+		{"middle double star no dirs", map[string]string{"": "a/**/z"}, "a/z", false, true},
+		{"trailing double star", map[string]string{"": "vendor/**"}, "vendor/x/y", false, true},
+		{"negation re-includes", map[string]string{"": "*.log\n!keep.log"}, "keep.log", false, false},
+		{"last match wins", map[string]string{"": "!keep.log\n*.log"}, "keep.log", false, true},
+		{"comments and blanks", map[string]string{"": "# *.go\n\n"}, "main.go", false, false},
+		{"escaped hash", map[string]string{"": `\#notes`}, "#notes", false, true},
+		{"trailing spaces", map[string]string{"": "tmp   "}, "tmp", false, true},
+		{"nested file applies below", map[string]string{"docs": "draft.md"}, "docs/draft.md", false, true},
+		{"nested file not outside", map[string]string{"docs": "draft.md"}, "draft.md", false, false},
+		{"nested anchored", map[string]string{"docs": "/site"}, "docs/site", true, true},
+		{"nested overrides root", map[string]string{"": "*.md", "docs": "!readme.md"}, "docs/readme.md", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New()
+			// The root's patterns come first, as when walking down the tree.
+			if p, ok := tt.patterns[""]; ok {
+				if err := m.Add(".", strings.NewReader(p)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for base, p := range tt.patterns {
+				if base == "" {
+					continue
+				}
+				if err := m.Add(base, strings.NewReader(p)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := m.Match(tt.path, tt.isDir); got != tt.want {
+				t.Errorf("Match(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,446 @@
+// Package index keeps what listing, searching and previewing need to know about every
+// template: its manifest metadata, and its directories and files with their sizes, content
+// hashes and, for text files, the distinct words they contain. Searching the contents of the
+// templates only looks at those words, so it never reads the files.
+//
+// The index is saved in the cache directory and refreshed incrementally: a root is listed
+// again only when its modification time changed, and a template is walked again only when
+// one of its directories or files changed, reusing the hashes of the files that did not.
+package index
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"open-template/internal/ignore"
+	"open-template/internal/manifest"
+)
+
+// version is bumped whenever the file format changes; older files are rebuilt.
+const version = 4
+
+// maxTextSize is the size above which a file's contents are not searched; its path still is.
+const maxTextSize = 1 << 20
+
+// Limits on the words indexed for a text file. Longer runs of letters and digits are mostly
+// hashes and encoded data, which nobody searches for and which would bloat the index.
+const (
+	maxWordLen = 40
+	maxWords   = 10000
+)
+
+// Index holds the templates of the roots. Like $PATH, a template in an earlier root
+// shadows one with the same name in a later root.
+type Index struct {
+	Version int      `json:"version"`
+	Roots   []Root   `json:"roots"`
+	Entries []*Entry `json:"entries"` // visible templates, in root order
+}
+
+// Root is a template root as it was last listed.
+type Root struct {
+	Path      string    `json:"path"`
+	ModTime   time.Time `json:"mod_time"`
+	Missing   bool      `json:"missing,omitempty"`
+	Templates []string  `json:"templates,omitempty"`
+}
+
+// Entry is what the index knows about one template.
+type Entry struct {
+	Name        string   `json:"name"`
+	Root        string   `json:"root"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Language    string   `json:"language,omitempty"`
+	Dirs        []Dir    `json:"dirs"`  // every directory, "." first, in walk order
+	Files       []File   `json:"files"` // in walk order
+}
+
+// Dir is a directory of a template. Its modification time changes when entries are added,
+// removed or renamed in it.
+type Dir struct {
+	Path    string      `json:"path"` // slash-separated, relative to the template directory
+	Mode    fs.FileMode `json:"mode"`
+	ModTime time.Time   `json:"mod_time"`
+}
+
+// File is a file of a template.
+type File struct {
+	Path    string      `json:"path"` // slash-separated, relative to the template directory
+	Size    int64       `json:"size"`
+	Mode    fs.FileMode `json:"mode"`
+	ModTime time.Time   `json:"mod_time"`
+	Link    string      `json:"link,omitempty"`   // target of a symlink
+	SHA256  string      `json:"sha256,omitempty"` // empty for symlinks and other special files
+	Words   []string    `json:"words,omitempty"`  // distinct lower-cased words of a text file, sorted
+}
+
+// Dir returns the path of the template directory.
+func (e *Entry) Dir() string {
+	return filepath.Join(e.Root, e.Name)
+}
+
+// Open loads the index saved in file and refreshes it against the roots, saving it back
+// if anything changed. A missing, unreadable or outdated file is rebuilt; failing to save
+// it only costs the next run a rebuild, so it is logged rather than returned.
+func Open(file string, roots []string) (*Index, error) {
+	idx := load(file)
+	changed, err := idx.Refresh(roots)
+	if err != nil {
+		return nil, err
+	}
+	if changed {
+		if err := idx.Save(file); err != nil {
+			slog.Warn("could not save template index", "file", file, "error", err)
+		}
+	}
+	return idx, nil
+}
+
+// Rebuild indexes the roots from scratch and saves the index in file.
+func Rebuild(file string, roots []string) (*Index, error) {
+	idx := &Index{Version: version}
+	if _, err := idx.Refresh(roots); err != nil {
+		return nil, err
+	}
+	return idx, idx.Save(file)
+}
+
+// load reads the index saved in file, or returns an empty one.
+func load(file string) *Index {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("could not read template index", "file", file, "error", err)
+		}
+		return &Index{Version: version}
+	}
+	idx := &Index{}
+	if err := json.Unmarshal(data, idx); err != nil || idx.Version != version {
+		slog.Info("rebuilding template index", "file", file, "reason", "unreadable or outdated")
+		return &Index{Version: version}
+	}
+	return idx
+}
+
+// Save writes the index to file, replacing it atomically.
+func (idx *Index) Save(file string) error {
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// Refresh brings the index up to date with the roots and reports whether it changed.
+// It replaces Roots and Entries rather than modifying them or the entries they hold,
+// so a copy of the index can be refreshed while the original is in use.
+func (idx *Index) Refresh(roots []string) (changed bool, err error) {
+	return idx.refresh(roots, true)
+}
+
+// Poll is a cheaper Refresh for checking the roots repeatedly. It only compares the
+// directories of each template, not its files: files added, removed or renamed are noticed,
+// as are files saved by editors that write a new file and rename it over the old one, but
+// not files modified in place. The next Refresh picks those up.
+func (idx *Index) Poll(roots []string) (changed bool, err error) {
+	return idx.refresh(roots, false)
+}
+
+// refresh implements Refresh, comparing the files of the templates only if files is set.
+func (idx *Index) refresh(roots []string, files bool) (changed bool, err error) {
+	start := time.Now()
+	oldRoots := map[string]Root{}
+	for _, r := range idx.Roots {
+		oldRoots[r.Path] = r
+	}
+	oldEntries := map[string]*Entry{}
+	for _, e := range idx.Entries {
+		oldEntries[e.Dir()] = e
+	}
+
+	var newRoots []Root
+	var entries []*Entry
+	seen := map[string]bool{}
+	reused := 0
+	for _, path := range roots {
+		root, err := listRoot(path, oldRoots[path])
+		if err != nil {
+			return false, err
+		}
+		newRoots = append(newRoots, root)
+		for _, name := range root.Templates {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			old := oldEntries[filepath.Join(path, name)]
+			if old != nil && old.fresh(files) {
+				entries = append(entries, old)
+				reused++
+				continue
+			}
+			e, err := buildEntry(path, name, old)
+			if err != nil {
+				return false, err
+			}
+			entries = append(entries, e)
+		}
+	}
+
+	changed = reused != len(entries) || len(entries) != len(idx.Entries) || !sameRoots(idx.Roots, newRoots)
+	idx.Roots, idx.Entries = newRoots, entries
+	slog.Debug("refreshed template index", "templates", len(entries), "reused", reused, "changed", changed, "duration", time.Since(start))
+	return changed, nil
+}
+
+// listRoot returns the templates of the root, reusing the old listing if the root did not change.
+// Hidden directories such as .git are not templates.
+func listRoot(path string, old Root) (Root, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		slog.Warn("template root does not exist", "root", path)
+		return Root{Path: path, Missing: true}, nil
+	}
+	if err != nil {
+		return Root{}, err
+	}
+	if !old.Missing && old.Path == path && old.ModTime.Equal(info.ModTime()) {
+		return old, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return Root{}, err
+	}
+	root := Root{Path: path, ModTime: info.ModTime()}
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			root.Templates = append(root.Templates, entry.Name())
+		}
+	}
+	slog.Debug("listed template root", "root", path, "count", len(root.Templates))
+	return root, nil
+}
+
+// sameRoots reports whether two root listings are identical.
+func sameRoots(a, b []Root) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Path != b[i].Path || a[i].Missing != b[i].Missing || !a[i].ModTime.Equal(b[i].ModTime) {
+			return false
+		}
+	}
+	return true
+}
+
+// fresh reports whether no directory of the template, nor any file if files is set,
+// changed since it was indexed.
+func (e *Entry) fresh(files bool) bool {
+	dir := e.Dir()
+	for _, d := range e.Dirs {
+		info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(d.Path)))
+		if err != nil || info.Mode() != d.Mode || !info.ModTime().Equal(d.ModTime) {
+			return false
+		}
+	}
+	if !files {
+		return true
+	}
+	for _, f := range e.Files {
+		info, err := os.Lstat(filepath.Join(dir, filepath.FromSlash(f.Path)))
+		if err != nil || info.Size() != f.Size || info.Mode() != f.Mode || !info.ModTime().Equal(f.ModTime) {
+			return false
+		}
+	}
+	return true
+}
+
+// buildEntry walks the template, reusing the hashes of the files of old that did not change.
+// Entries that are never part of a template, such as .git, are skipped as NewPlan skips them.
+func buildEntry(root, name string, old *Entry) (*Entry, error) {
+	e := &Entry{Name: name, Root: root}
+	dir := e.Dir()
+	m, err := manifest.Load(dir)
+	switch {
+	case err == nil:
+		e.Description, e.Tags, e.Language = m.Description, m.Tags, m.Language
+	case !errors.Is(err, os.ErrNotExist):
+		slog.Warn("indexing template without its manifest", "template", dir, "error", err)
+	}
+
+	known := map[string]File{}
+	if old != nil {
+		for _, f := range old.Files {
+			known[f.Path] = f
+		}
+	}
+	hashed := 0
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && ignore.Always(d.Name()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			e.Dirs = append(e.Dirs, Dir{Path: rel, Mode: info.Mode(), ModTime: info.ModTime()})
+			return nil
+		}
+
+		f := File{Path: rel, Size: info.Size(), Mode: info.Mode(), ModTime: info.ModTime()}
+		if k, ok := known[rel]; ok && k.Size == f.Size && k.Mode == f.Mode && k.ModTime.Equal(f.ModTime) {
+			f = k
+		} else if f.Mode&fs.ModeSymlink != 0 {
+			if f.Link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		} else if f.Mode.IsRegular() {
+			if f.SHA256, f.Words, err = hashFile(path, info.Size()); err != nil {
+				return err
+			}
+			hashed++
+		}
+		e.Files = append(e.Files, f)
+		return nil
+	})
+	slog.Debug("indexed template", "template", dir, "files", len(e.Files), "hashed", hashed)
+	return e, err
+}
+
+// hashFile returns the checksum of the file and, if it is a text file small enough to search,
+// its words.
+func hashFile(path string, size int64) (sum string, words []string, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	var r io.Reader = f
+	var buf bytes.Buffer
+	if size <= maxTextSize {
+		r = io.TeeReader(f, &buf)
+	}
+	if _, err := io.Copy(h, r); err != nil {
+		return "", nil, err
+	}
+	if size <= maxTextSize && manifest.IsText(buf.Bytes()) {
+		words = Words(buf.String(), maxWords)
+	}
+	return hex.EncodeToString(h.Sum(nil)), words, nil
+}
+
+// Words returns the distinct words of text, lower-cased and sorted, at most limit of them
+// if limit is positive. A word is a run of at least two letters, digits or underscores, of at
+// most maxWordLen.
+func Words(text string, limit int) []string {
+	seen := map[string]bool{}
+	var words []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), notWordRune) {
+		if len(w) < 2 || len(w) > maxWordLen || seen[w] {
+			continue
+		}
+		if limit > 0 && len(words) == limit {
+			break
+		}
+		seen[w] = true
+		words = append(words, w)
+	}
+	sort.Strings(words)
+	return words
+}
+
+func notWordRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+}
+
+// Lookup returns the entry of the named template.
+func (idx *Index) Lookup(name string) (*Entry, bool) {
+	for _, e := range idx.Entries {
+		if e.Name == name {
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+// Names returns the names of the indexed templates.
+func (idx *Index) Names() []string {
+	names := make([]string, len(idx.Entries))
+	for i, e := range idx.Entries {
+		names[i] = e.Name
+	}
+	return names
+}
+
+// HasTag reports whether the template is tagged with tag, ignoring case.
+func (e *Entry) HasTag(tag string) bool {
+	for _, t := range e.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// FindFile returns the first file whose path contains text, or whose words contain each word
+// of text, ignoring case. Path matches are preferred, so "dockerfile" finds the Dockerfile
+// before a README mentioning it, and "postgres" finds a file mentioning PostgreSQL.
+func (e *Entry) FindFile(text string) (path string, ok bool) {
+	text = strings.ToLower(text)
+	for _, f := range e.Files {
+		if strings.Contains(strings.ToLower(f.Path), text) {
+			return f.Path, true
+		}
+	}
+	query := Words(text, 0)
+	if len(query) == 0 {
+		return "", false
+	}
+files:
+	for _, f := range e.Files {
+		for _, q := range query {
+			if !slices.ContainsFunc(f.Words, func(w string) bool { return strings.Contains(w, q) }) {
+				continue files
+			}
+		}
+		return f.Path, true
+	}
+	return "", false
+}
@@ -0,0 +1,179 @@
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFile creates the file at the slash-separated path below dir, with a fixed
+// modification time so a later change is always noticed.
+func writeFile(t *testing.T, dir, rel, content string, mtime time.Time) {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func entry(t *testing.T, idx *Index, name string) *Entry {
+	t.Helper()
+	e, ok := idx.Lookup(name)
+	if !ok {
+		t.Fatalf("template %q not indexed; have %q", name, idx.Names())
+	}
+	return e
+}
+
+func file(t *testing.T, e *Entry, path string) File {
+	t.Helper()
+	for _, f := range e.Files {
+		if f.Path == path {
+			return f
+		}
+	}
+	t.Fatalf("file %q of %s not indexed", path, e.Name)
+	return File{}
+}
+
+func TestRefreshReusesUnchangedTemplates(t *testing.T) {
+	root := t.TempDir()
+	past := time.Now().Add(-time.Hour)
+	writeFile(t, root, "api/main.go", "package main\n", past)
+	writeFile(t, root, "api/README.md", "# API\n", past)
+	writeFile(t, root, "web/index.html", "<h1>web</h1>\n", past)
+
+	idx := &Index{Version: version}
+	if changed, err := idx.Refresh([]string{root}); err != nil || !changed {
+		t.Fatalf("first Refresh = %v, %v; want changed", changed, err)
+	}
+	api, web := entry(t, idx, "api"), entry(t, idx, "web")
+	readme := file(t, api, "README.md")
+
+	// Nothing changed: every entry is reused as is.
+	if changed, err := idx.Refresh([]string{root}); err != nil || changed {
+		t.Fatalf("Refresh without changes = %v, %v; want unchanged", changed, err)
+	}
+	if entry(t, idx, "api") != api || entry(t, idx, "web") != web {
+		t.Error("Refresh without changes rebuilt entries")
+	}
+
+	// A file modified in place: only its template is walked again, and only it is hashed again.
+	writeFile(t, root, "api/main.go", "package main\n\nfunc main() {}\n", past.Add(time.Minute))
+	if changed, err := idx.Poll([]string{root}); err != nil || changed {
+		t.Errorf("Poll after in-place edit = %v, %v; want unchanged", changed, err)
+	}
+	if changed, err := idx.Refresh([]string{root}); err != nil || !changed {
+		t.Fatalf("Refresh after edit = %v, %v; want changed", changed, err)
+	}
+	if entry(t, idx, "web") != web {
+		t.Error("unchanged template web was rebuilt")
+	}
+	newAPI := entry(t, idx, "api")
+	if newAPI == api {
+		t.Fatal("edited template api was reused")
+	}
+	if got := file(t, newAPI, "main.go"); got.SHA256 == file(t, api, "main.go").SHA256 || got.Size != 29 {
+		t.Errorf("main.go = %+v, want the new hash and size", got)
+	}
+	if got := file(t, newAPI, "README.md"); !reflect.DeepEqual(got, readme) {
+		t.Errorf("README.md = %+v, want it reused: %+v", got, readme)
+	}
+
+	// A file added: Poll notices through the directory's modification time.
+	writeFile(t, root, "web/app.js", "console.log(1)\n", past)
+	if err := os.Chtimes(filepath.Join(root, "web"), time.Now(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := idx.Poll([]string{root}); err != nil || !changed {
+		t.Fatalf("Poll after adding a file = %v, %v; want changed", changed, err)
+	}
+	file(t, entry(t, idx, "web"), "app.js")
+
+	// A template removed from the root.
+	if err := os.RemoveAll(filepath.Join(root, "web")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(root, time.Now().Add(time.Minute), time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := idx.Poll([]string{root}); err != nil || !changed {
+		t.Fatalf("Poll after removing a template = %v, %v; want changed", changed, err)
+	}
+	if _, ok := idx.Lookup("web"); ok {
+		t.Error("removed template web still indexed")
+	}
+}
+
+func TestIndexSkipsGitAndStoresWords(t *testing.T) {
+	root := t.TempDir()
+	past := time.Now().Add(-time.Hour)
+	hash := strings.Repeat("ab12", 16)
+	writeFile(t, root, "api/main.go", "package main // PostgreSQL driver, sum "+hash+"\n", past)
+	writeFile(t, root, "api/README.md", "# API\n\nRun go mod tidy.\n", past)
+	writeFile(t, root, "api/.git/HEAD", "ref: refs/heads/main\n", past)
+	writeFile(t, root, "api/lib/.git", "gitdir: ../.git/modules/lib\n", past)
+
+	indexFile := filepath.Join(t.TempDir(), "index.json")
+	idx, err := Rebuild(indexFile, []string{root})
+	if err != nil {
+		t.Fatalf("Rebuild: %v", err)
+	}
+	e := entry(t, idx, "api")
+	for _, f := range e.Files {
+		if strings.Contains(f.Path, ".git") {
+			t.Errorf("indexed %s", f.Path)
+		}
+	}
+	for _, d := range e.Dirs {
+		if strings.Contains(d.Path, ".git") {
+			t.Errorf("indexed directory %s", d.Path)
+		}
+	}
+
+	data, err := os.ReadFile(indexFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "PostgreSQL driver") || strings.Contains(string(data), hash) {
+		t.Errorf("index.json holds file contents or overlong words: %s", data)
+	}
+	if got, want := file(t, e, "main.go").Words, []string{"driver", "main", "package", "postgresql", "sum"}; !slices.Equal(got, want) {
+		t.Errorf("words of main.go = %q, want %q", got, want)
+	}
+
+	// Contents are searched through the saved words.
+	reopened, err := Open(indexFile, []string{root})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	tests := []struct {
+		text string
+		want string
+		ok   bool
+	}{
+		{"MAIN", "main.go", true},
+		{"readme", "README.md", true},
+		{"postgres", "main.go", true},
+		{"Postgres Driver", "main.go", true},
+		{"mod tidy", "README.md", true},
+		{"tidy postgres", "", false},
+		{"refs/heads", "", false},
+		{"mysql", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := entry(t, reopened, "api").FindFile(tt.text); got != tt.want || ok != tt.ok {
+			t.Errorf("FindFile(%q) = %q, %v; want %q, %v", tt.text, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// Package logging sets up the structured logger shared by every subsystem.
+//
+// Headless commands log to stderr. While the TUI owns the terminal, logs go to a
+// size-rotated file in the state directory instead, so they can be attached to bug reports.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	maxLogSize = 5 << 20 // rotate the log file once it grows past this size
+	maxBackups = 3       // rotated files kept: open-template.log.1 ... .3
+)
+
+// Options controls where and what is logged.
+type Options struct {
+	Level   string // debug, info, warn or error
+	Verbose bool   // forces the debug level
+	File    string // log to this rotating file instead of stderr
+}
+
+// Setup installs the default slog logger and returns a function closing its output.
+func Setup(opts Options) (func() error, error) {
+	level, err := ParseLevel(opts.Level)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Verbose {
+		level = slog.LevelDebug
+	}
+
+	var out io.Writer = os.Stderr
+	closeFn := func() error { return nil }
+	if opts.File != "" {
+		w, err := openRotating(opts.File)
+		if err != nil {
+			return nil, err
+		}
+		out, closeFn = w, w.Close
+		// The file is invisible while working, so it always keeps at least info.
+		level = min(level, slog.LevelInfo)
+	}
+
+	handler := slog.NewTextHandler(out, &slog.HandlerOptions{Level: level})
+	slog.SetDefault(slog.New(handler))
+	return closeFn, nil
+}
+
+// ParseLevel converts a level name into a slog level.
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info", "":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q", name)
+}
+
+// rotatingWriter appends to a file, moving it aside once it exceeds maxLogSize.
+type rotatingWriter struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+	size int64
+}
+
+func openRotating(path string) (*rotatingWriter, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	w := &rotatingWriter{path: path}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *rotatingWriter) open() error {
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.file, w.size = f, info.Size()
+	return nil
+}
+
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.size+int64(len(p)) > maxLogSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// rotate shifts open-template.log.N to .N+1, dropping the oldest, and starts a new file.
+func (w *rotatingWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	for i := maxBackups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", w.path, i), fmt.Sprintf("%s.%d", w.path, i+1))
+	}
+	if err := os.Rename(w.path, w.path+".1"); err != nil {
+		return err
+	}
+	return w.open()
+}
+
+func (w *rotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
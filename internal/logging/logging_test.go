@@ -0,0 +1,147 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    slog.Level
+		wantErr bool
+	}{
+		{"", slog.LevelInfo, false},
+		{"debug", slog.LevelDebug, false},
+		{"INFO", slog.LevelInfo, false},
+		{"warn", slog.LevelWarn, false},
+		{"warning", slog.LevelWarn, false},
+		{"error", slog.LevelError, false},
+		{"verbose", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       Options
+		toFile     bool
+		wantDebug  bool // a debug record is written
+		wantInfo   bool // an info record is written
+		wantSetErr bool
+	}{
+		{name: "stderr at warn", opts: Options{Level: "warn"}},
+		{name: "stderr verbose", opts: Options{Level: "error", Verbose: true}, wantDebug: true, wantInfo: true},
+		{name: "file keeps info", opts: Options{Level: "error"}, toFile: true, wantInfo: true},
+		{name: "file verbose", opts: Options{Verbose: true}, toFile: true, wantDebug: true, wantInfo: true},
+		{name: "bad level", opts: Options{Level: "loud"}, wantSetErr: true},
+	}
+	defer slog.SetDefault(slog.Default())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			stderr, err := os.Create(filepath.Join(dir, "stderr"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer stderr.Close()
+			saved := os.Stderr
+			os.Stderr = stderr
+			defer func() { os.Stderr = saved }()
+
+			logFile := filepath.Join(dir, "logs", "open-template.log")
+			if tt.toFile {
+				tt.opts.File = logFile
+			}
+			closeLog, err := Setup(tt.opts)
+			if (err != nil) != tt.wantSetErr {
+				t.Fatalf("Setup = %v, want error %v", err, tt.wantSetErr)
+			}
+			if err != nil {
+				return
+			}
+			slog.Debug("debug record")
+			slog.Info("info record")
+			slog.Warn("warn record")
+			if err := closeLog(); err != nil {
+				t.Fatalf("close: %v", err)
+			}
+
+			written, _ := os.ReadFile(stderr.Name())
+			logged, _ := os.ReadFile(logFile)
+			out, other := written, logged
+			if tt.toFile {
+				out, other = logged, written
+			}
+			if len(other) > 0 {
+				t.Errorf("records written to the other output: %s", other)
+			}
+			for _, c := range []struct {
+				msg  string
+				want bool
+			}{{"debug record", tt.wantDebug}, {"info record", tt.wantInfo}, {"warn record", true}} {
+				if got := bytes.Contains(out, []byte(c.msg)); got != c.want {
+					t.Errorf("%q logged: %v, want %v; output:\n%s", c.msg, got, c.want, out)
+				}
+			}
+		})
+	}
+}
+
+func TestRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "open-template.log")
+	w, err := openRotating(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	// Each chunk is a fifth of the limit, so every fifth write after the first rotates.
+	// This makes maxBackups+1 rotations and leaves three chunks in the current file.
+	chunk := []byte(strings.Repeat("x", maxLogSize/5-1) + "\n")
+	for i := 0; i < 5*(maxBackups+2)-2; i++ {
+		if _, err := w.Write(chunk); err != nil {
+			t.Fatalf("write %d: %v", i, err)
+		}
+	}
+
+	for _, name := range []string{path, path + ".1", path + ".2", path + ".3"} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Errorf("%s: %v", filepath.Base(name), err)
+			continue
+		}
+		if info.Size() > maxLogSize {
+			t.Errorf("%s is %d bytes, over the %d byte limit", filepath.Base(name), info.Size(), maxLogSize)
+		}
+	}
+	if _, err := os.Stat(fmt.Sprintf("%s.%d", path, maxBackups+1)); err == nil {
+		t.Errorf("more than %d backups kept", maxBackups)
+	}
+
+	// A reopened log appends after the existing contents instead of truncating them.
+	before, _ := os.Stat(path)
+	w.Close()
+	w, err = openRotating(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if _, err := w.Write([]byte("again\n")); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := os.Stat(path); after.Size() != before.Size()+6 {
+		t.Errorf("reopened log is %d bytes, want %d", after.Size(), before.Size()+6)
+	}
+}
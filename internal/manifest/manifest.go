@@ -0,0 +1,103 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// FileName is the name of the manifest file at the root of a template.
+const FileName = "template.json"
+
+// Variable describes a value that is substituted into a template at generation time.
+type Variable struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Default     string `json:"default,omitempty"`
+}
+
+// Manifest holds the metadata of a single template.
+type Manifest struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	Language    string     `json:"language,omitempty"`
+	Variables   []Variable `json:"variables,omitempty"`
+
+	// Hooks are shell commands run in the project directory after its files are
+	// generated, in order. They are not rendered; variables are passed to them as
+	// OPEN_TEMPLATE_<NAME> environment variables.
+	Hooks []string `json:"hooks,omitempty"`
+}
+
+// Load reads the manifest of the template rooted at dir.
+// The returned error wraps os.ErrNotExist if the template has no manifest.
+func Load(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, FileName))
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", FileName, err)
+	}
+	return m, nil
+}
+
+// Save writes m as the manifest of the template rooted at dir.
+func Save(dir string, m *Manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, FileName), append(data, '\n'), 0644)
+}
+
+// Variable returns the declared variable with the given name, if any.
+func (m *Manifest) Variable(name string) (Variable, bool) {
+	for _, v := range m.Variables {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return Variable{}, false
+}
+
+// Values returns the values of the variables for generating a project: the default of each
+// declared variable, overridden by given. A manifest may be nil, in which case given is used
+// as is. It fails for a name in given that the manifest does not declare, and for a declared
+// variable left without a value, so generation stops before it writes anything.
+func (m *Manifest) Values(given map[string]string) (map[string]string, error) {
+	values := map[string]string{}
+	for name, value := range given {
+		values[name] = value
+	}
+	if m == nil {
+		return values, nil
+	}
+
+	var unknown []string
+	for name := range given {
+		if _, ok := m.Variable(name); !ok && name != ProjectNameVar {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("the template declares no variable %q", unknown[0])
+	}
+	for _, v := range m.Variables {
+		if _, ok := values[v.Name]; !ok && v.Default != "" {
+			values[v.Name] = v.Default
+		}
+	}
+	for _, v := range m.Variables {
+		if _, ok := values[v.Name]; !ok {
+			return nil, fmt.Errorf("variable %q has no default and no value was given", v.Name)
+		}
+	}
+	return values, nil
+}
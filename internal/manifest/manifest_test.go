@@ -0,0 +1,66 @@
+package manifest
+
+import (
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestValues(t *testing.T) {
+	m := &Manifest{Variables: []Variable{
+		{Name: "Author"},
+		{Name: "Port", Default: "8080"},
+		{Name: ProjectNameVar},
+	}}
+	tests := []struct {
+		name     string
+		manifest *Manifest
+		given    map[string]string
+		want     map[string]string
+		err      string
+	}{
+		{
+			name:     "defaults fill in",
+			manifest: m,
+			given:    map[string]string{ProjectNameVar: "billing", "Author": "Jane"},
+			want:     map[string]string{ProjectNameVar: "billing", "Author": "Jane", "Port": "8080"},
+		},
+		{
+			name:     "given values override defaults",
+			manifest: m,
+			given:    map[string]string{ProjectNameVar: "billing", "Author": "Jane", "Port": "9090"},
+			want:     map[string]string{ProjectNameVar: "billing", "Author": "Jane", "Port": "9090"},
+		},
+		{
+			name:     "variable without a value",
+			manifest: m,
+			given:    map[string]string{ProjectNameVar: "billing"},
+			err:      `variable "Author" has no default`,
+		},
+		{
+			name:     "undeclared variable",
+			manifest: m,
+			given:    map[string]string{ProjectNameVar: "billing", "Author": "Jane", "Owner": "x"},
+			err:      `declares no variable "Owner"`,
+		},
+		{
+			name:  "no manifest",
+			given: map[string]string{ProjectNameVar: "billing", "Owner": "x"},
+			want:  map[string]string{ProjectNameVar: "billing", "Owner": "x"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.manifest.Values(tt.given)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Values = %v, %v; want error containing %q", got, err, tt.err)
+				}
+				return
+			}
+			if err != nil || !maps.Equal(got, tt.want) {
+				t.Errorf("Values = %v, %v; want %v", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package manifest
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// ProjectNameVar is the variable every template receives, holding the name of the new project.
+const ProjectNameVar = "ProjectName"
+
+// TemplateSuffix marks a file whose placeholders are rendered even in a template without a
+// manifest. It is dropped from the name of the generated file.
+const TemplateSuffix = ".tmpl"
+
+// Rendered reports whether the placeholders in the contents of the template file at rel are
+// rendered when a project is generated. A template opts in by having a manifest, which renders
+// every text file and path; without one, only *.tmpl files are rendered and everything else is
+// copied as is, so files using "{{" for other tools, such as GitHub Actions workflows or Helm
+// charts, are left alone.
+func Rendered(rel string, hasManifest bool) bool {
+	return hasManifest || strings.HasSuffix(rel, TemplateSuffix) && len(filepath.Base(rel)) > len(TemplateSuffix)
+}
+
+// OutputPath returns the path generated for the template file or directory at rel: rendered
+// with vars if the template has a manifest, and without the TemplateSuffix of a file.
+func OutputPath(rel string, isDir, hasManifest bool, vars map[string]string) (string, error) {
+	out := rel
+	if hasManifest {
+		var err error
+		if out, err = Render(rel, rel, vars); err != nil {
+			return "", err
+		}
+	}
+	if !isDir && Rendered(rel, false) {
+		out = strings.TrimSuffix(out, TemplateSuffix)
+	}
+	return out, nil
+}
+
+// Placeholder returns the template action that expands to the named variable.
+func Placeholder(name string) string {
+	return "{{." + name + "}}"
+}
+
+// Escape quotes existing template delimiters in text so it renders back to itself.
+func Escape(text string) string {
+	return strings.ReplaceAll(text, "{{", `{{"{{"}}`)
+}
+
+// IsTemplated reports whether text contains template actions that need rendering.
+func IsTemplated(text string) bool {
+	return strings.Contains(text, "{{")
+}
+
+// Parse parses text as a template, failing on references to missing variables when executed.
+func Parse(name, text string) (*template.Template, error) {
+	return template.New(name).Option("missingkey=error").Parse(text)
+}
+
+// Render expands text with the given variable values.
+func Render(name, text string, vars map[string]string) (string, error) {
+	if !IsTemplated(text) {
+		return text, nil
+	}
+	tmpl, err := Parse(name, text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Fields returns the names of the top-level variables referenced by text, e.g. "ProjectName" for {{.ProjectName}}.
+func Fields(name, text string) ([]string, error) {
+	if !IsTemplated(text) {
+		return nil, nil
+	}
+	tmpl, err := Parse(name, text)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	var fields []string
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				walk(cmd)
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg)
+			}
+		case *parse.FieldNode:
+			if len(n.Ident) > 0 && !seen[n.Ident[0]] {
+				seen[n.Ident[0]] = true
+				fields = append(fields, n.Ident[0])
+			}
+		case *parse.IfNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.TemplateNode:
+			walk(n.Pipe)
+		}
+	}
+	walk(tmpl.Tree.Root)
+	return fields, nil
+}
+
+// SniffSize is how much of a file IsText looks at.
+const SniffSize = 8000
+
+// IsText reports whether data looks like text rather than binary content.
+// Only text files are rendered; binary files are copied verbatim.
+func IsText(data []byte) bool {
+	if len(data) > SniffSize {
+		data = data[:SniffSize]
+	}
+	return !bytes.Contains(data, []byte{0})
+}
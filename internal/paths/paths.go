@@ -0,0 +1,92 @@
+// Package paths is the single place that decides where open-template keeps its files.
+//
+// It follows the XDG Base Directory specification: configuration lives under
+// $XDG_CONFIG_HOME/open-template, caches under $XDG_CACHE_HOME/open-template and
+// logs and trust data under $XDG_STATE_HOME/open-template. Unset or relative
+// variables fall back to ~/.config, ~/.cache and ~/.local/state; on Windows the
+// fallbacks are the roaming and local application data directories.
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+const appName = "open-template"
+
+// ConfigDir returns the directory holding configuration and credentials.
+func ConfigDir() (string, error) {
+	return baseDir("XDG_CONFIG_HOME", ".config", os.UserConfigDir)
+}
+
+// CacheDir returns the directory holding data that can be rebuilt at any time.
+func CacheDir() (string, error) {
+	return baseDir("XDG_CACHE_HOME", ".cache", os.UserCacheDir)
+}
+
+// StateDir returns the directory holding data worth keeping between runs that is not configuration.
+func StateDir() (string, error) {
+	return baseDir("XDG_STATE_HOME", filepath.Join(".local", "state"), localAppData)
+}
+
+// ConfigFile returns the default config file.
+func ConfigFile() (string, error) {
+	return join(ConfigDir, "config.toml")
+}
+
+// CredentialsFile returns the file storing registry tokens.
+func CredentialsFile() (string, error) {
+	return join(ConfigDir, "credentials.json")
+}
+
+// IndexFile returns the cached template index.
+func IndexFile() (string, error) {
+	return join(CacheDir, "index.json")
+}
+
+// TrustFile returns the file recording which templates may run hooks.
+func TrustFile() (string, error) {
+	return join(StateDir, "trust.json")
+}
+
+// LogFile returns the log written while the TUI owns the terminal.
+func LogFile() (string, error) {
+	return join(StateDir, "open-template.log")
+}
+
+// baseDir resolves an XDG base directory for the application.
+func baseDir(env, homeRel string, platform func() (string, error)) (string, error) {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return filepath.Join(dir, appName), nil
+	}
+	if runtime.GOOS == "windows" {
+		dir, err := platform()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, appName), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, homeRel, appName), nil
+}
+
+// join appends name to the directory returned by dir.
+func join(dir func() (string, error), name string) (string, error) {
+	d, err := dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(d, name), nil
+}
+
+// localAppData is the Windows fallback for the state directory.
+func localAppData() (string, error) {
+	if dir := os.Getenv("LocalAppData"); dir != "" {
+		return dir, nil
+	}
+	return os.UserCacheDir()
+}
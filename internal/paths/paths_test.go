@@ -0,0 +1,49 @@
+package paths
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestConfigDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fallback is the roaming application data directory")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	tests := []struct {
+		xdg, want string
+	}{
+		{"/xdg", filepath.Join("/xdg", appName)},
+		{"", filepath.Join(home, ".config", appName)},
+		{"relative", filepath.Join(home, ".config", appName)},
+	}
+	for _, tt := range tests {
+		t.Setenv("XDG_CONFIG_HOME", tt.xdg)
+		if got, err := ConfigDir(); err != nil || got != tt.want {
+			t.Errorf("ConfigDir with XDG_CONFIG_HOME=%q = %q, %v; want %q", tt.xdg, got, err, tt.want)
+		}
+	}
+}
+
+func TestFiles(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/config")
+	t.Setenv("XDG_CACHE_HOME", "/cache")
+	t.Setenv("XDG_STATE_HOME", "/state")
+	tests := []struct {
+		file func() (string, error)
+		want string
+	}{
+		{ConfigFile, filepath.Join("/config", appName, "config.toml")},
+		{CredentialsFile, filepath.Join("/config", appName, "credentials.json")},
+		{IndexFile, filepath.Join("/cache", appName, "index.json")},
+		{TrustFile, filepath.Join("/state", appName, "trust.json")},
+		{LogFile, filepath.Join("/state", appName, "open-template.log")},
+	}
+	for _, tt := range tests {
+		if got, err := tt.file(); err != nil || got != tt.want {
+			t.Errorf("got %q, %v; want %q", got, err, tt.want)
+		}
+	}
+}
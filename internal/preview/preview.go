@@ -0,0 +1,170 @@
+// Package preview prepares template files for the file viewer of the TUI: text files are
+// syntax highlighted by their extension, optionally after rendering their placeholders with
+// sample values, and binary files are described by their size and type.
+package preview
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"open-template/internal/manifest"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// MaxSize is the size above which a text file is described rather than shown.
+const MaxSize = 1 << 20
+
+// Style is the chroma style used for highlighting.
+const Style = "catppuccin-mocha"
+
+// sampleProjectName is the project name files are rendered with.
+const sampleProjectName = "my-project"
+
+// File is a template file prepared for viewing.
+type File struct {
+	Name     string // path relative to the template directory, used to pick the lexer
+	Size     int64
+	Binary   bool   // not shown: a binary file, or a text file over MaxSize
+	Type     string // MIME type guessed from the contents
+	Language string // name of the lexer, empty if none matched
+	Text     string // contents of a text file
+}
+
+// Load reads the file at path for viewing. name is its path relative to the template.
+func Load(path, name string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, errors.New("is a directory")
+	}
+
+	data, err := io.ReadAll(io.LimitReader(f, MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	file := &File{Name: name, Size: info.Size(), Type: http.DetectContentType(data)}
+	if len(data) > MaxSize || !manifest.IsText(data) {
+		file.Binary = true
+		return file, nil
+	}
+	file.Text = string(data)
+	if lexer := lexers.Match(name); lexer != nil {
+		file.Language = lexer.Config().Name
+	}
+	return file, nil
+}
+
+// Describe summarizes a file the viewer does not show, e.g. "image/png · 12.3 KiB".
+func (f *File) Describe() string {
+	parts := []string{f.Type, FormatSize(f.Size)}
+	if f.Size > MaxSize {
+		parts = append(parts, "too large to preview")
+	}
+	return strings.Join(parts, " · ")
+}
+
+// Highlight returns the lines of text with ANSI colors for its language, picked by the file
+// name or, failing that, by the contents. Every line is colored on its own, so lines can be
+// shown apart from each other. Text no lexer recognizes is returned as is.
+func Highlight(name, text string) []string {
+	plain := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	lexer := lexers.Match(name)
+	if lexer == nil {
+		lexer = lexers.Analyse(text)
+	}
+	if lexer == nil {
+		return plain
+	}
+	it, err := chroma.Coalesce(lexer).Tokenise(nil, text)
+	if err != nil {
+		return plain
+	}
+	style := styles.Get(Style)
+	var lines []string
+	for _, tokens := range chroma.SplitTokensIntoLines(it.Tokens()) {
+		// The newline ends the last token; keep it out of the colors.
+		if last := len(tokens) - 1; last >= 0 {
+			tokens[last].Value = strings.TrimSuffix(tokens[last].Value, "\n")
+		}
+		var sb strings.Builder
+		if err := formatters.TTY256.Format(&sb, style, chroma.Literator(tokens...)); err != nil {
+			return plain
+		}
+		lines = append(lines, sb.String())
+	}
+	return lines
+}
+
+// Render renders the placeholders of the file's name and contents with sample values:
+// "my-project" for the project name, and the default or the name in angle brackets for
+// the other variables. m is nil for a template without a manifest, whose files are
+// generated as they are, except *.tmpl files (see manifest.Rendered).
+func (f *File) Render(m *manifest.Manifest) (name, text string, err error) {
+	hasManifest := m != nil
+	vars := map[string]string{manifest.ProjectNameVar: sampleProjectName}
+	var sources []string // where the variables used are looked for
+	switch {
+	case hasManifest:
+		sources = []string{f.Name, f.Text}
+	case manifest.Rendered(f.Name, false):
+		sources = []string{f.Text}
+	}
+	for _, source := range sources {
+		fields, err := manifest.Fields(f.Name, source)
+		if err != nil {
+			return "", "", err
+		}
+		for _, field := range fields {
+			if _, ok := vars[field]; ok {
+				continue
+			}
+			vars[field] = "<" + field + ">"
+			if m == nil {
+				continue
+			}
+			if v, ok := m.Variable(field); ok && v.Default != "" {
+				vars[field] = v.Default
+			}
+		}
+	}
+	if name, err = manifest.OutputPath(f.Name, false, hasManifest, vars); err != nil {
+		return "", "", err
+	}
+	text = f.Text
+	if manifest.Rendered(f.Name, hasManifest) {
+		if text, err = manifest.Render(f.Name, f.Text, vars); err != nil {
+			return "", "", err
+		}
+	}
+	return name, text, nil
+}
+
+// FormatSize formats a size in bytes with a binary unit, e.g. "12.3 KiB".
+func FormatSize(size int64) string {
+	if size < 1024 {
+		return fmt.Sprintf("%d B", size)
+	}
+	value, unit := float64(size)/1024, "KiB"
+	for _, next := range []string{"MiB", "GiB", "TiB"} {
+		if value < 1024 {
+			break
+		}
+		value, unit = value/1024, next
+	}
+	return fmt.Sprintf("%.1f %s", value, unit)
+}
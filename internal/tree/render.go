@@ -0,0 +1,242 @@
+package tree
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"path"
+	"strings"
+
+	style "open-template/internal/ui/style"
+)
+
+// Formats are the formats Render accepts.
+var Formats = []string{"color", "ascii", "markdown", "json", "html"}
+
+// Render renders the tree in one of Formats.
+func Render(format string, root *Node) (string, error) {
+	switch format {
+	case "color":
+		return root.Name + "\n" + Color(root), nil
+	case "ascii":
+		return ASCII(root), nil
+	case "markdown":
+		return Markdown(root), nil
+	case "json":
+		return JSON(root)
+	case "html":
+		return HTML(root), nil
+	}
+	return "", fmt.Errorf("unknown tree format %q; use one of %s", format, strings.Join(Formats, ", "))
+}
+
+// Row is a line of a drawn tree: a node, or the error listing a directory, after its connectors.
+type Row struct {
+	Node   *Node
+	Path   string // slash-separated, relative to the top
+	Prefix string // connectors and indentation
+	Error  bool   // the row shows Node.Err rather than Node
+	Level  int    // 1 for the entries of the top
+}
+
+// Rows flattens the entries below root into the rows they are drawn in, descending into
+// the directories open reports as open.
+func Rows(root *Node, open func(*Node) bool) []Row {
+	var rows []Row
+	var walk func(n *Node, dir, prefix string, level int)
+	walk = func(n *Node, dir, prefix string, level int) {
+		if n.Err != nil {
+			rows = append(rows, Row{Node: n, Path: dir, Prefix: prefix, Error: true, Level: level})
+			return
+		}
+		for i, child := range n.Children {
+			connector, indent := "├╼ ", "│  "
+			if i == len(n.Children)-1 {
+				connector, indent = "└╼ ", "   "
+			}
+			rel := path.Join(dir, child.Name)
+			rows = append(rows, Row{Node: child, Path: rel, Prefix: prefix + connector, Level: level})
+			if child.Kind == Dir && open(child) {
+				walk(child, rel, prefix+indent, level+1)
+			}
+		}
+	}
+	walk(root, ".", "", 1)
+	return rows
+}
+
+// Color renders the entries below root as the TUI preview shows them.
+func Color(root *Node) string {
+	var sb strings.Builder
+	for _, row := range Rows(root, func(*Node) bool { return true }) {
+		sb.WriteString(ColorRow(row, false) + "\n")
+	}
+	if sb.Len() == 0 {
+		return Empty()
+	}
+	return sb.String()
+}
+
+// Empty renders the colored view of a tree without entries.
+func Empty() string {
+	return style.TreeEmptyStyle.Render("E\nM\nP\nT\nY")
+}
+
+// ColorRow renders a row of the colored view; folded marks a directory whose entries are hidden.
+func ColorRow(row Row, folded bool) string {
+	if row.Error {
+		return row.Prefix + style.ErrorStyle.Render(fmt.Sprintf("Error reading directory: %v", row.Node.Err))
+	}
+	name := colorName(row.Node)
+	if folded && row.Node.Kind == Dir && (len(row.Node.Children) > 0 || row.Node.Err != nil) {
+		name += style.TreeLinkStyle.Render(" …")
+	}
+	return row.Prefix + name
+}
+
+// colorName renders the name of a node in the colored view.
+func colorName(n *Node) string {
+	switch n.Kind {
+	case Dir:
+		return style.TreeIconStyle.Render(" ") + style.TreeDirStyle.Render(n.Name)
+	case Symlink:
+		return style.TreeFileStyle.Render(n.Name) + style.TreeLinkStyle.Render(" → "+n.Target)
+	}
+	return style.TreeFileStyle.Render(n.Name)
+}
+
+// ASCII renders the tree with plain ASCII connectors, like tree(1) with --charset ascii.
+func ASCII(root *Node) string {
+	var sb strings.Builder
+	sb.WriteString(plainName(root) + "\n")
+	var walk func(n *Node, prefix string)
+	walk = func(n *Node, prefix string) {
+		if n.Err != nil {
+			sb.WriteString(prefix + "`-- [error: " + n.Err.Error() + "]\n")
+			return
+		}
+		for i, child := range n.Children {
+			connector, indent := "|-- ", "|   "
+			if i == len(n.Children)-1 {
+				connector, indent = "`-- ", "    "
+			}
+			sb.WriteString(prefix + connector + plainName(child) + "\n")
+			walk(child, prefix+indent)
+		}
+	}
+	walk(root, "")
+	return sb.String()
+}
+
+// plainName is the name of a node in the plain text formats: directories end with a slash
+// and symlinks show their target.
+func plainName(n *Node) string {
+	switch n.Kind {
+	case Dir:
+		return n.Name + "/"
+	case Symlink:
+		return n.Name + " -> " + n.Target
+	}
+	return n.Name
+}
+
+// Markdown renders the tree as a nested Markdown list.
+func Markdown(root *Node) string {
+	var sb strings.Builder
+	var walk func(n *Node, indent string)
+	walk = func(n *Node, indent string) {
+		sb.WriteString(indent + "- " + code(plainName(n)) + "\n")
+		if n.Err != nil {
+			sb.WriteString(indent + "  - *error: " + n.Err.Error() + "*\n")
+			return
+		}
+		for _, child := range n.Children {
+			walk(child, indent+"  ")
+		}
+	}
+	walk(root, "")
+	return sb.String()
+}
+
+// code renders text as a Markdown code span, fenced with enough backticks for the text.
+func code(text string) string {
+	fence := "`"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		text = " " + text + " "
+	}
+	return fence + text + fence
+}
+
+// jsonNode is the JSON form of a node.
+type jsonNode struct {
+	Name      string      `json:"name"`
+	Kind      Kind        `json:"kind"`
+	Size      int64       `json:"size,omitempty"`
+	Mode      string      `json:"mode"`
+	Target    string      `json:"target,omitempty"`
+	Truncated bool        `json:"truncated,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	Children  []*jsonNode `json:"children,omitempty"`
+}
+
+// JSON renders the tree as an indented JSON object with the fields of every node; modes
+// are written as by ls, e.g. "-rw-r--r--".
+func JSON(root *Node) (string, error) {
+	var convert func(n *Node) *jsonNode
+	convert = func(n *Node) *jsonNode {
+		j := &jsonNode{Name: n.Name, Kind: n.Kind, Size: n.Size, Mode: n.Mode.String(), Target: n.Target, Truncated: n.Truncated}
+		if n.Err != nil {
+			j.Error = n.Err.Error()
+		}
+		for _, child := range n.Children {
+			j.Children = append(j.Children, convert(child))
+		}
+		return j
+	}
+	var sb strings.Builder
+	enc := json.NewEncoder(&sb)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(convert(root)); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// HTML renders the tree as a standalone HTML page of nested lists.
+func HTML(root *Node) string {
+	var sb strings.Builder
+	sb.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	sb.WriteString("<title>" + html.EscapeString(root.Name) + "</title>\n")
+	sb.WriteString("<style>\n" +
+		"ul.tree, ul.tree ul { list-style: none; padding-left: 1.2em; font-family: monospace; }\n" +
+		"ul.tree .dir { font-weight: bold; }\n" +
+		"ul.tree .error { color: #c00; }\n" +
+		"</style>\n</head>\n<body>\n<ul class=\"tree\">\n")
+	var walk func(n *Node, indent string)
+	walk = func(n *Node, indent string) {
+		sb.WriteString(indent + `<li class="` + string(n.Kind) + `"`)
+		if n.Kind == File {
+			sb.WriteString(fmt.Sprintf(` title="%d bytes"`, n.Size))
+		}
+		sb.WriteString(">" + html.EscapeString(plainName(n)))
+		if n.Err == nil && len(n.Children) == 0 {
+			sb.WriteString("</li>\n")
+			return
+		}
+		sb.WriteString("\n" + indent + "  <ul>\n")
+		if n.Err != nil {
+			sb.WriteString(indent + `    <li class="error">` + html.EscapeString(n.Err.Error()) + "</li>\n")
+		}
+		for _, child := range n.Children {
+			walk(child, indent+"    ")
+		}
+		sb.WriteString(indent + "  </ul>\n" + indent + "</li>\n")
+	}
+	walk(root, "")
+	sb.WriteString("</ul>\n</body>\n</html>\n")
+	return sb.String()
+}
@@ -0,0 +1,173 @@
+package tree
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"reflect"
+	"slices"
+	"testing"
+
+	"open-template/internal/index"
+)
+
+// testEntry is an indexed template with nested directories, files sorted out of order and a symlink.
+var testEntry = &index.Entry{
+	Name: "svc",
+	Dirs: []index.Dir{
+		{Path: ".", Mode: fs.ModeDir | 0755},
+		{Path: "cmd", Mode: fs.ModeDir | 0755},
+		{Path: "cmd/svc", Mode: fs.ModeDir | 0755},
+	},
+	Files: []index.File{
+		{Path: "README.md", Size: 10, Mode: 0644},
+		{Path: "cmd/svc/main.go", Size: 42, Mode: 0644},
+		{Path: "link", Mode: fs.ModeSymlink | 0777, Link: "README.md"},
+		{Path: "go.mod", Size: 20, Mode: 0644},
+	},
+}
+
+func walkEntry(t *testing.T, maxDepth int) *Node {
+	t.Helper()
+	root, err := Walk("svc", Entry(testEntry), maxDepth)
+	if err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+	return root
+}
+
+func TestRows(t *testing.T) {
+	type row struct {
+		path, prefix string
+		level        int
+	}
+	tests := []struct {
+		name string
+		open func(*Node) bool
+		want []row
+	}{
+		{
+			name: "all open",
+			open: func(*Node) bool { return true },
+			want: []row{
+				{"cmd", "├╼ ", 1},
+				{"cmd/svc", "│  └╼ ", 2},
+				{"cmd/svc/main.go", "│     └╼ ", 3},
+				{"go.mod", "├╼ ", 1},
+				{"link", "├╼ ", 1},
+				{"README.md", "└╼ ", 1},
+			},
+		},
+		{
+			name: "folded",
+			open: func(*Node) bool { return false },
+			want: []row{
+				{"cmd", "├╼ ", 1},
+				{"go.mod", "├╼ ", 1},
+				{"link", "├╼ ", 1},
+				{"README.md", "└╼ ", 1},
+			},
+		},
+		{
+			name: "only top directory open",
+			open: func(n *Node) bool { return n.Name == "cmd" },
+			want: []row{
+				{"cmd", "├╼ ", 1},
+				{"cmd/svc", "│  └╼ ", 2},
+				{"go.mod", "├╼ ", 1},
+				{"link", "├╼ ", 1},
+				{"README.md", "└╼ ", 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []row
+			for _, r := range Rows(walkEntry(t, -1), tt.open) {
+				got = append(got, row{r.Path, r.Prefix, r.Level})
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Rows =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRowsError(t *testing.T) {
+	root := &Node{Name: "svc", Kind: Dir, Children: []*Node{
+		{Name: "locked", Kind: Dir, Err: errors.New("permission denied")},
+	}}
+	rows := Rows(root, func(*Node) bool { return true })
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want the directory and its error", len(rows))
+	}
+	if rows[0].Error || !rows[1].Error || rows[1].Prefix != "   " || rows[1].Level != 2 {
+		t.Errorf("rows = %+v, want the error below the directory", rows)
+	}
+}
+
+func TestASCII(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxDepth int
+		want     string
+	}{
+		{
+			name:     "full",
+			maxDepth: -1,
+			want: "svc/\n" +
+				"|-- cmd/\n" +
+				"|   `-- svc/\n" +
+				"|       `-- main.go\n" +
+				"|-- go.mod\n" +
+				"|-- link -> README.md\n" +
+				"`-- README.md\n",
+		},
+		{
+			name:     "depth 1",
+			maxDepth: 1,
+			want: "svc/\n" +
+				"|-- cmd/\n" +
+				"|-- go.mod\n" +
+				"|-- link -> README.md\n" +
+				"`-- README.md\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ASCII(walkEntry(t, tt.maxDepth)); got != tt.want {
+				t.Errorf("ASCII =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSON(t *testing.T) {
+	root := walkEntry(t, 1)
+	root.Children = append(root.Children, &Node{Name: "locked", Kind: Dir, Mode: fs.ModeDir | 0700, Err: errors.New("permission denied")})
+
+	out, err := JSON(root)
+	if err != nil {
+		t.Fatalf("JSON: %v", err)
+	}
+	var got jsonNode
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("JSON output does not parse: %v\n%s", err, out)
+	}
+
+	want := map[string]jsonNode{
+		"cmd":       {Name: "cmd", Kind: Dir, Mode: "drwxr-xr-x", Truncated: true},
+		"go.mod":    {Name: "go.mod", Kind: File, Size: 20, Mode: "-rw-r--r--"},
+		"link":      {Name: "link", Kind: Symlink, Mode: "Lrwxrwxrwx", Target: "README.md"},
+		"README.md": {Name: "README.md", Kind: File, Size: 10, Mode: "-rw-r--r--"},
+		"locked":    {Name: "locked", Kind: Dir, Mode: "drwx------", Error: "permission denied"},
+	}
+	if got.Name != "svc" || got.Kind != Dir || len(got.Children) != len(want) {
+		t.Fatalf("root = %s %s with %d children, want svc dir with %d", got.Name, got.Kind, len(got.Children), len(want))
+	}
+	for _, child := range got.Children {
+		if w, ok := want[child.Name]; !ok || !reflect.DeepEqual(*child, w) {
+			t.Errorf("node %s = %+v, want %+v", child.Name, *child, w)
+		}
+	}
+}
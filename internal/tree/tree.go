@@ -0,0 +1,150 @@
+// Package tree builds the file tree of a template, from the index or from the disk, and
+// renders it in several formats: the colored view of the TUI preview, plain ASCII,
+// Markdown, JSON and HTML.
+package tree
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"open-template/internal/index"
+)
+
+// Kind is the kind of a node.
+type Kind string
+
+const (
+	Dir     Kind = "dir"
+	File    Kind = "file"
+	Symlink Kind = "symlink"
+	Other   Kind = "other" // devices, sockets, named pipes
+)
+
+// Node is a file or directory of a tree.
+type Node struct {
+	Name      string
+	Kind      Kind
+	Size      int64
+	Mode      fs.FileMode
+	Target    string  // target of a symlink
+	Children  []*Node // of a directory: directories first, then files, each by name ignoring case
+	Truncated bool    // a directory below the depth limit, whose children were not listed
+	Err       error   // why the children of a directory could not be listed
+}
+
+// Source lists the entries of a directory, given its slash-separated path relative to the
+// top of the tree, which is ".". The nodes it returns have no children yet.
+type Source func(dir string) ([]*Node, error)
+
+// Walk builds the tree named name from the entries src lists, down to maxDepth levels
+// below the top, or fully if maxDepth is negative. Only failing to list the top is an
+// error; a directory below it that cannot be listed keeps the error in its Err.
+// The mode of the top is left to the caller, as sources only describe what is below it.
+func Walk(name string, src Source, maxDepth int) (*Node, error) {
+	root := &Node{Name: name, Kind: Dir, Mode: fs.ModeDir}
+	children, err := src(".")
+	if err != nil {
+		return nil, err
+	}
+	root.Children = sorted(children)
+
+	var walk func(n *Node, dir string, depth int)
+	walk = func(n *Node, dir string, depth int) {
+		for _, child := range n.Children {
+			if child.Kind != Dir {
+				continue
+			}
+			if maxDepth >= 0 && depth >= maxDepth {
+				child.Truncated = true
+				continue
+			}
+			rel := path.Join(dir, child.Name)
+			children, err := src(rel)
+			if err != nil {
+				child.Err = err
+				continue
+			}
+			child.Children = sorted(children)
+			walk(child, rel, depth+1)
+		}
+	}
+	walk(root, ".", 1)
+	return root, nil
+}
+
+// sorted orders the entries of a directory: directories first, then files, each by name ignoring case.
+func sorted(nodes []*Node) []*Node {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if (nodes[i].Kind == Dir) != (nodes[j].Kind == Dir) {
+			return nodes[i].Kind == Dir
+		}
+		return strings.ToLower(nodes[i].Name) < strings.ToLower(nodes[j].Name)
+	})
+	return nodes
+}
+
+// Disk lists the directories below dir on the disk.
+func Disk(dir string) Source {
+	return func(rel string) ([]*Node, error) {
+		full := filepath.Join(dir, filepath.FromSlash(rel))
+		entries, err := os.ReadDir(full)
+		if err != nil {
+			return nil, err
+		}
+		nodes := make([]*Node, 0, len(entries))
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil {
+				// Removed since it was listed.
+				continue
+			}
+			n := newNode(entry.Name(), info.Mode(), info.Size())
+			if n.Kind == Symlink {
+				n.Target, _ = os.Readlink(filepath.Join(full, entry.Name()))
+			}
+			nodes = append(nodes, n)
+		}
+		return nodes, nil
+	}
+}
+
+// Entry lists the directories of an indexed template, without touching the disk.
+func Entry(e *index.Entry) Source {
+	children := map[string][]*Node{}
+	for _, d := range e.Dirs {
+		if d.Path != "." {
+			parent := path.Dir(d.Path)
+			children[parent] = append(children[parent], newNode(path.Base(d.Path), d.Mode|fs.ModeDir, 0))
+		}
+	}
+	for _, f := range e.Files {
+		parent := path.Dir(f.Path)
+		n := newNode(path.Base(f.Path), f.Mode, f.Size)
+		n.Target = f.Link
+		children[parent] = append(children[parent], n)
+	}
+	return func(rel string) ([]*Node, error) {
+		// The walk sorts the slice it is given, so it gets its own.
+		return append([]*Node(nil), children[rel]...), nil
+	}
+}
+
+// newNode returns the node of an entry with the given mode.
+func newNode(name string, mode fs.FileMode, size int64) *Node {
+	n := &Node{Name: name, Mode: mode}
+	switch {
+	case mode.IsDir():
+		n.Kind = Dir
+	case mode&fs.ModeSymlink != 0:
+		n.Kind = Symlink
+	case mode.IsRegular():
+		n.Kind, n.Size = File, size
+	default:
+		n.Kind = Other
+	}
+	return n
+}
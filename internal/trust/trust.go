@@ -0,0 +1,95 @@
+// Package trust records which template hooks the user has approved.
+//
+// Hooks are shell commands from the template, so they only run once the user has seen and
+// approved them. An approval covers the exact list of hooks of one template directory;
+// changing the hooks asks again.
+package trust
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Store holds the approved hooks, keyed by absolute template directory.
+type Store struct {
+	path      string
+	Templates map[string]string `json:"templates"` // template directory -> digest of its hooks
+}
+
+// Open loads the trust store at path. A missing file yields an empty store.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, Templates: map[string]string{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if s.Templates == nil {
+		s.Templates = map[string]string{}
+	}
+	return s, nil
+}
+
+// Save writes the store back to disk, readable only by the current user.
+func (s *Store) Save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".trust-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// Trusted reports whether the user approved exactly these hooks for the template.
+func (s *Store) Trusted(template string, hooks []string) bool {
+	key, err := filepath.Abs(template)
+	if err != nil {
+		return false
+	}
+	digest, ok := s.Templates[key]
+	return ok && digest == Digest(hooks)
+}
+
+// Trust records the user's approval of the template's hooks.
+func (s *Store) Trust(template string, hooks []string) error {
+	key, err := filepath.Abs(template)
+	if err != nil {
+		return err
+	}
+	s.Templates[key] = Digest(hooks)
+	return nil
+}
+
+// Digest identifies a list of hooks.
+func Digest(hooks []string) string {
+	h := sha256.New()
+	for _, hook := range hooks {
+		h.Write([]byte(hook))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
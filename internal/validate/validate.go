@@ -0,0 +1,217 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"open-template/internal/index"
+	"open-template/internal/manifest"
+)
+
+// DefaultMaxFileSize is the size above which a template file is reported as suspicious.
+const DefaultMaxFileSize int64 = 10 << 20
+
+// Severity tells whether an issue breaks the template or merely looks wrong.
+type Severity int
+
+const (
+	Warning Severity = iota
+	Error
+)
+
+func (s Severity) String() string {
+	if s == Error {
+		return "error"
+	}
+	return "warning"
+}
+
+// Issue is a single problem found in a template.
+type Issue struct {
+	Template string
+	Path     string // slash-separated path inside the template, "" for the template itself
+	Severity Severity
+	Message  string
+}
+
+func (i Issue) String() string {
+	if i.Path == "" {
+		return fmt.Sprintf("%s: %s: %s", i.Template, i.Severity, i.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s: %s", i.Template, i.Severity, i.Path, i.Message)
+}
+
+// Options tunes the checks.
+type Options struct {
+	// MaxFileSize is the largest file size accepted without a warning. Zero uses DefaultMaxFileSize.
+	MaxFileSize int64
+}
+
+// HasErrors reports whether any of the issues is an error.
+func HasErrors(issues []Issue) bool {
+	for _, i := range issues {
+		if i.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
+
+// Template checks the indexed template and returns every issue found. It goes through the
+// directories and files of the index, which skips .git as generation does, and only reads the
+// files generation renders, up to MaxFileSize.
+func Template(e *index.Entry, opts Options) []Issue {
+	if opts.MaxFileSize <= 0 {
+		opts.MaxFileSize = DefaultMaxFileSize
+	}
+
+	var issues []Issue
+	report := func(path string, sev Severity, format string, args ...any) {
+		issues = append(issues, Issue{Template: e.Name, Path: path, Severity: sev, Message: fmt.Sprintf(format, args...)})
+	}
+
+	root, err := filepath.Abs(e.Dir())
+	if err != nil {
+		report("", Error, "%v", err)
+		return issues
+	}
+
+	// Manifest: it is optional, but when present it must parse and declare each variable once,
+	// preferably with a default.
+	declared := map[string]bool{}
+	m, err := manifest.Load(root)
+	hasManifest := !errors.Is(err, fs.ErrNotExist)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		report("", Warning, "no %s manifest", manifest.FileName)
+	case err != nil:
+		report(manifest.FileName, Error, "%v", err)
+	default:
+		for _, v := range m.Variables {
+			if v.Name == "" {
+				report(manifest.FileName, Error, "variable without a name")
+				continue
+			}
+			if declared[v.Name] {
+				report(manifest.FileName, Error, "variable %q declared more than once", v.Name)
+			}
+			declared[v.Name] = true
+			// Only new --var can set a variable without a default; the TUI cannot.
+			if v.Default == "" && v.Name != manifest.ProjectNameVar {
+				report(manifest.FileName, Warning, "variable %q has no default, so it must be set with new --var", v.Name)
+			}
+		}
+	}
+
+	// used maps each referenced variable to the first path using it.
+	used := map[string]string{}
+	collect := func(rel, text string) {
+		fields, err := manifest.Fields(rel, text)
+		if err != nil {
+			report(rel, Error, "invalid template: %v", err)
+			return
+		}
+		for _, f := range fields {
+			if _, ok := used[f]; !ok {
+				used[f] = rel
+			}
+		}
+	}
+
+	// Only the files and paths generation renders are parsed (see manifest.Rendered).
+	for _, entry := range walkOrder(e) {
+		rel := entry.Path
+		if rel == "." || rel == manifest.FileName {
+			continue
+		}
+		if hasManifest {
+			collect(rel, rel)
+		}
+
+		switch {
+		case entry.Mode&fs.ModeSymlink != 0:
+			checkSymlink(root, rel, entry.Link, report)
+		case entry.Mode.IsRegular():
+			if entry.Size > opts.MaxFileSize {
+				report(rel, Warning, "file is %d bytes, over the %d byte limit", entry.Size, opts.MaxFileSize)
+				continue
+			}
+			if !manifest.Rendered(rel, hasManifest) {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(rel)))
+			if err != nil {
+				report(rel, Error, "unreadable: %v", err)
+				continue
+			}
+			if manifest.IsText(data) {
+				collect(rel, string(data))
+			}
+		}
+	}
+
+	// ProjectName is always provided, so it needs no declaration.
+	for _, f := range sortedKeys(used) {
+		if f != manifest.ProjectNameVar && !declared[f] {
+			report(used[f], Error, "variable %q is not declared in %s", f, manifest.FileName)
+		}
+	}
+	for _, v := range sortedKeys(declared) {
+		if _, ok := used[v]; !ok {
+			report(manifest.FileName, Warning, "variable %q is declared but never used", v)
+		}
+	}
+	return issues
+}
+
+// walkOrder returns the directories and files of the template as index.Files, in the order a
+// walk of the template visits them, so reports read top to bottom.
+func walkOrder(e *index.Entry) []index.File {
+	entries := make([]index.File, 0, len(e.Dirs)+len(e.Files))
+	for _, d := range e.Dirs {
+		entries = append(entries, index.File{Path: d.Path, Mode: d.Mode})
+	}
+	entries = append(entries, e.Files...)
+	slices.SortStableFunc(entries, func(a, b index.File) int {
+		return slices.Compare(strings.Split(a.Path, "/"), strings.Split(b.Path, "/"))
+	})
+	return entries
+}
+
+// checkSymlink reports symlinks that are broken or point outside the template.
+func checkSymlink(root, rel, target string, report func(string, Severity, string, ...any)) {
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	resolved := target
+	if !filepath.IsAbs(resolved) {
+		resolved = filepath.Join(filepath.Dir(path), target)
+	}
+	inside := resolved == root || strings.HasPrefix(filepath.Clean(resolved), root+string(filepath.Separator))
+	switch {
+	case !inside && filepath.IsAbs(target):
+		report(rel, Error, "absolute symlink to %s escapes the template", target)
+	case !inside:
+		report(rel, Error, "symlink to %s escapes the template", target)
+	case filepath.IsAbs(target):
+		report(rel, Warning, "absolute symlink to %s will break once the template is copied", target)
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		report(rel, Error, "broken symlink to %s", target)
+	}
+}
+
+// sortedKeys returns the keys of m in order, so reports are stable between runs.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
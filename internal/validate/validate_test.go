@@ -0,0 +1,113 @@
+package validate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"open-template/internal/index"
+)
+
+func TestTemplate(t *testing.T) {
+	const workflow = "run: echo ${{ github.sha }}\n"
+
+	tests := []struct {
+		name  string
+		files map[string]string
+		opts  Options
+		want  []string // prefix of Issue.String() of every issue, in order
+	}{
+		{
+			name: "no manifest copies workflows as is",
+			files: map[string]string{
+				".github/workflows/ci.yml": workflow,
+				"README.md.tmpl":           "# {{.ProjectName}}\n",
+			},
+			want: []string{"t: warning: no template.json manifest"},
+		},
+		{
+			name: "no manifest renders tmpl files",
+			files: map[string]string{
+				"README.md.tmpl": "# {{.Title\n",
+				"LICENSE.tmpl":   "{{.Owner}}",
+			},
+			want: []string{
+				"t: warning: no template.json manifest",
+				"t: error: README.md.tmpl: invalid template:",
+				`t: error: LICENSE.tmpl: variable "Owner" is not declared in template.json`,
+			},
+		},
+		{
+			name: "manifest renders every file",
+			files: map[string]string{
+				"template.json":            `{"name": "t", "variables": [{"name": "Owner", "default": "me"}, {"name": "Unused", "default": "x"}]}`,
+				".github/workflows/ci.yml": workflow,
+				"{{.Owner}}.txt":           "",
+			},
+			want: []string{
+				"t: error: .github/workflows/ci.yml: invalid template:",
+				`t: warning: template.json: variable "Unused" is declared but never used`,
+			},
+		},
+		{
+			name: "variable without a default",
+			files: map[string]string{
+				"template.json": `{"name": "t", "variables": [{"name": "Author"}, {"name": "ProjectName"}]}`,
+				"LICENSE":       "{{.Author}} {{.ProjectName}}",
+			},
+			want: []string{`t: warning: template.json: variable "Author" has no default`},
+		},
+		{
+			name: "git directory is skipped",
+			files: map[string]string{
+				"template.json":   `{"name": "t"}`,
+				".git/HEAD":       "{{ not a template",
+				".git/hooks/post": "{{.Missing}}",
+			},
+		},
+		{
+			name: "files over the limit are not read",
+			files: map[string]string{
+				"template.json": `{"name": "t"}`,
+				"big.txt":       "{{.Missing}} " + strings.Repeat("x", 64),
+			},
+			opts: Options{MaxFileSize: 32},
+			want: []string{"t: warning: big.txt: file is 77 bytes, over the 32 byte limit"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			dir := filepath.Join(root, "t")
+			for rel, content := range tt.files {
+				path := filepath.Join(dir, filepath.FromSlash(rel))
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			idx := &index.Index{}
+			if _, err := idx.Refresh([]string{root}); err != nil {
+				t.Fatal(err)
+			}
+			e, ok := idx.Lookup("t")
+			if !ok {
+				t.Fatal("template t not indexed")
+			}
+			var got []string
+			for _, issue := range Template(e, tt.opts) {
+				got = append(got, issue.String())
+			}
+			ok = len(got) == len(tt.want)
+			for i := 0; ok && i < len(got); i++ {
+				ok = strings.HasPrefix(got[i], tt.want[i])
+			}
+			if !ok {
+				t.Errorf("issues:\n%q\nwant:\n%q", got, tt.want)
+			}
+		})
+	}
+}
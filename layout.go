@@ -0,0 +1,82 @@
+package main
+
+import (
+	style "open-template/internal/ui/style"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Bounds of the layout. Terminals narrower than minTwoColumnWidth show the preview below
+// the list instead of next to it.
+const (
+	minTwoColumnWidth = 72
+	minListWidth      = 33 // room for the instructions below the list
+	maxListWidth      = 60
+	minListHeight     = 3
+	minPreviewHeight  = 4 // below this the preview is left out
+)
+
+// Size assumed until the terminal reports its own.
+const (
+	defaultWidth  = 100
+	defaultHeight = 30
+)
+
+// layout is the size of every part of the screen, derived from the terminal size.
+type layout struct {
+	single bool // one column: the preview is below the list
+
+	listWidth  int // width of the left panel, padding included
+	listHeight int // lines of the left panel
+
+	previewWidth  int
+	previewHeight int // lines of the preview pane; 0 when there is no room for it
+
+	viewerWidth  int
+	viewerHeight int // lines of file contents
+}
+
+// layout sizes the panels for the terminal, given the instructions shown below the list.
+func (m model) layout(instructions string) layout {
+	width, height := m.width, m.height
+	if width == 0 || height == 0 {
+		width, height = defaultWidth, defaultHeight
+	}
+
+	// The document margins, the header and the final newline; one more line keeps the
+	// screen from scrolling.
+	header := lipgloss.Height(style.HeaderStyle.Render(headerTitle))
+	available := height - style.DocStyle.GetMarginTop() - header - 2
+	columns := width - style.DocStyle.GetMarginLeft()
+
+	// Border and margin around the left panel, padding right of the preview.
+	listFrame := style.LeftPanelStyle.GetHorizontalBorderSize() + style.LeftPanelStyle.GetMarginRight()
+	listBorder := style.LeftPanelStyle.GetVerticalBorderSize()
+	previewPadding := style.RightPanelStyle.GetPaddingRight()
+	previewMargin := style.RightPanelStyle.GetMarginTop()
+
+	var l layout
+	belowList := listBorder + lipgloss.Height(instructions)
+	if columns < minTwoColumnWidth {
+		l.single = true
+		l.listWidth = max(columns-listFrame, 1)
+		l.previewWidth = max(columns-previewPadding, 1)
+		// The list gets half of the room and the preview the rest, if that is enough for it.
+		l.listHeight = max((available-belowList)/2, minListHeight)
+		l.previewHeight = available - belowList - l.listHeight - previewMargin
+		if l.previewHeight < minPreviewHeight {
+			l.previewHeight = 0
+			l.listHeight = max(available-belowList, minListHeight)
+		}
+	} else {
+		l.listWidth = min(max(columns*2/5, minListWidth), maxListWidth)
+		l.previewWidth = columns - l.listWidth - listFrame - previewPadding
+		l.listHeight = max(available-belowList, minListHeight)
+		l.previewHeight = max(available-previewMargin, minPreviewHeight)
+	}
+
+	// The viewer has a title and a footer, each followed or preceded by a blank line.
+	l.viewerWidth = max(columns, 1)
+	l.viewerHeight = max(available-4, 1)
+	return l
+}
@@ -0,0 +1,68 @@
+package main
+
+import "testing"
+
+func TestLayout(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		instructions  string
+		want          layout
+	}{
+		{
+			name:         "size not reported yet",
+			instructions: "enter: select",
+			want:         layout{listWidth: 39, listHeight: 19, previewWidth: 54, previewHeight: 21, viewerWidth: 98, viewerHeight: 18},
+		},
+		{
+			name:  "wide list is capped",
+			width: 200, height: 50,
+			instructions: "enter: select",
+			want:         layout{listWidth: 60, listHeight: 39, previewWidth: 133, previewHeight: 41, viewerWidth: 198, viewerHeight: 38},
+		},
+		{
+			name:  "list keeps room for the instructions",
+			width: 80, height: 30,
+			instructions: "enter: select",
+			want:         layout{listWidth: 33, listHeight: 19, previewWidth: 40, previewHeight: 21, viewerWidth: 78, viewerHeight: 18},
+		},
+		{
+			name:  "instructions on two lines",
+			width: 100, height: 30,
+			instructions: "enter: select\nq: quit",
+			want:         layout{listWidth: 39, listHeight: 18, previewWidth: 54, previewHeight: 21, viewerWidth: 98, viewerHeight: 18},
+		},
+		{
+			name:  "short terminal keeps the minimum heights",
+			width: 100, height: 10,
+			instructions: "enter: select",
+			want:         layout{listWidth: 39, listHeight: minListHeight, previewWidth: 54, previewHeight: minPreviewHeight, viewerWidth: 98, viewerHeight: 1},
+		},
+		{
+			name:  "narrow terminal stacks the preview",
+			width: 60, height: 40,
+			instructions: "enter: select",
+			want:         layout{single: true, listWidth: 55, listHeight: 14, previewWidth: 56, previewHeight: 14, viewerWidth: 58, viewerHeight: 28},
+		},
+		{
+			name:  "narrow and short terminal leaves the preview out",
+			width: 60, height: 16,
+			instructions: "enter: select",
+			want:         layout{single: true, listWidth: 55, listHeight: 5, previewWidth: 56, viewerWidth: 58, viewerHeight: 4},
+		},
+		{
+			name:  "tiny terminal",
+			width: 10, height: 5,
+			instructions: "enter: select",
+			want:         layout{single: true, listWidth: 5, listHeight: minListHeight, previewWidth: 6, viewerWidth: 8, viewerHeight: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := model{width: tt.width, height: tt.height}
+			if got := m.layout(tt.instructions); got != tt.want {
+				t.Errorf("layout(%d×%d) = %+v, want %+v", tt.width, tt.height, got, tt.want)
+			}
+		})
+	}
+}
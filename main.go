@@ -2,64 +2,82 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"io"
-	"io/fs"
+	"log/slog"
 	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
-	style "template-manager/internal/ui/style"
-	"template-manager/utils"
+	"open-template/generator"
+	"open-template/internal/config"
+	"open-template/internal/index"
+	"open-template/internal/logging"
+	"open-template/internal/paths"
+	"open-template/internal/tree"
+	style "open-template/internal/ui/style"
+	"open-template/utils"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
-// Absolute route to your template folder.
-const templateDir string = "/Users/ayushkumar/programming/templates"
-
 // ----- Application Stages -----
 const (
 	stageSelectTemplate = iota
 	stageProjectName
+	stagePlanning
+	stageConfirmHooks
 	stageCopying
 	stageDone
 )
 
+// headerTitle is the title in the header of every screen.
+const headerTitle = "Template Manager ⚡"
+
 // blinkMsg is sent periodically to toggle the blink state.
 type blinkMsg struct{}
 
 // ----- Data Types -----
-// op represents a file or directory operation.
-type op struct {
-	opType  string // "mkdir" or "copy"
-	relPath string
-}
-
 // model holds the application state.
 type model struct {
 	stage int
 
 	// Stage 0: Template selection.
-	templates []string
-	cursor    int
+	templates    []string
+	templateDirs map[string]string // template name -> template directory
+	cursor       int
+	roots        []string    // template roots, polled for changes
+	treeFocused  bool        // keys move through the preview pane instead of the list
+	viewer       *fileViewer // file opened from the preview pane, shown instead of the panes
 
 	// Search-related fields for template selection.
+	index         *index.Index
 	searchMode    bool
+	searchFiles   bool // find templates by file path or contents instead of name and metadata
 	searchQuery   string
-	searchResults []string
+	searchResults []utils.Match // best match first
 	searchCursor  int
 
 	// Stage 1: Project name input.
 	inputBuffer string
+	inputErr    error // why the entered name was rejected
 	projectName string
 
-	// Stage 2: Copying process.
-	ops            []op // list of operations to perform
-	currentOpIndex int
+	// Hooks of the template not approved yet: the plan waits for the user's answer.
+	plan *generator.Plan
+
+	// Stage 2: Copying process, driven by the same events as 'new --progress jsonl'.
+	// ctx covers the whole run, from planning to the end of copying; cancel stops it.
+	events     chan generator.Event
+	opsTotal   int // operations and hooks in the plan
+	opsDone    int
+	ctx        context.Context
+	cancel     context.CancelFunc
+	cancelling bool   // ctrl+c was pressed; waiting for the rollback
+	onCancel   string // what an interrupted run leaves behind: rollback or keep
 
 	// A single log message - only one log appears at a time.
 	currentLog string
@@ -73,6 +91,7 @@ type model struct {
 
 	// Tree depth parameter; negative means unlimited.
 	treeDepth int
+	previews  *previewCache
 
 	// Blink state for cursor.
 	blink bool
@@ -80,20 +99,40 @@ type model struct {
 	// Show help instructions panel.
 	showHelp bool
 
+	// Terminal size, 0 until it is reported.
+	width  int
+	height int
+
 	// Error (if any).
 	err error
 }
 
 // Messages for the copying process.
-type opProcessedMsg struct {
-	op  op
-	err error
-}
+type progressMsg generator.Event
 
 type copyFinishedMsg struct{}
 
+// planReadyMsg delivers the plan of the project, and whether its hooks are approved.
+type planReadyMsg struct {
+	plan    *generator.Plan
+	trusted bool
+	err     error
+}
+
 // ----- Helper Functions -----
 
+// truncate shortens text to at most width runes, marking the cut with an ellipsis.
+func truncate(text string, width int) string {
+	runes := []rune(text)
+	if len(runes) <= width {
+		return text
+	}
+	if width <= 1 {
+		return ""
+	}
+	return string(runes[:width-1]) + "…"
+}
+
 // clipText clips the given text to a maximum number of lines.
 func clipText(text string, maxLines int) string {
 	lines := strings.Split(text, "\n")
@@ -104,102 +143,75 @@ func clipText(text string, maxLines int) string {
 	return strings.Join(lines, "\n")
 }
 
-// copyFile copies a file from src to dst.
-func copyFile(src, dst string) error {
-	in, err := os.Open(src)
-	if err != nil {
-		return err
-	}
-	defer in.Close()
-
-	// Ensure the destination directory exists.
-	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
-		return err
-	}
-
-	out, err := os.Create(dst)
-	if err != nil {
-		return err
-	}
-	defer out.Close()
-
-	_, err = io.Copy(out, in)
-	return err
-}
-
-// buildOps walks the source directory and builds a list of operations.
-func buildOps(source string) ([]op, error) {
-	var ops []op
-	err := filepath.Walk(source, func(path string, info fs.FileInfo, err error) error {
-		if err != nil {
-			return err
+// startGenerate executes the plan in the background and returns the channel of its events,
+// closed when it ends.
+func startGenerate(ctx context.Context, plan *generator.Plan, onCancel string) chan generator.Event {
+	events := make(chan generator.Event)
+	go func() {
+		defer close(events)
+		// Failures, including cancellation, arrive as an error event.
+		opts := generator.Options{
+			OnConflict: generator.Fail,
+			OnCancel:   generator.CancelPolicy(onCancel),
+			Journal:    true,
+			Observer:   generator.Events(func(e generator.Event) { events <- e }),
 		}
+		generator.Execute(ctx, plan, opts)
+	}()
+	return events
+}
 
-		rel, err := filepath.Rel(source, path)
+// planProject walks the template in the background, stopping early once ctx is done.
+func planProject(ctx context.Context, sourceDir, destDir string, vars map[string]string) tea.Cmd {
+	return func() tea.Msg {
+		plan, err := generator.NewPlan(ctx, sourceDir, destDir, vars)
 		if err != nil {
-			return err
-		}
-		if rel == "." {
-			return nil
+			return planReadyMsg{err: err}
 		}
+		trusted, err := hooksTrusted(plan)
+		return planReadyMsg{plan: plan, trusted: trusted, err: err}
+	}
+}
 
-		if info.IsDir() {
-			ops = append(ops, op{opType: "mkdir", relPath: rel})
-		} else {
-			ops = append(ops, op{opType: "copy", relPath: rel})
-		}
-		return nil
-	})
-	return ops, err
+// startCopying moves to the copying stage, generating the project from plan.
+func (m model) startCopying(plan *generator.Plan) (tea.Model, tea.Cmd) {
+	m.currentLog = ""
+	m.stage = stageCopying
+	// Begin generating; the spinner is already ticking since planning.
+	m.events = startGenerate(m.ctx, plan, m.onCancel)
+	return m, waitForEvent(m.events)
 }
 
-// nextOpCmd processes the next operation.
-func nextOpCmd(m model) tea.Cmd {
+// waitForEvent delivers the next generation event.
+func waitForEvent(events chan generator.Event) tea.Cmd {
 	return func() tea.Msg {
-		if m.currentOpIndex >= len(m.ops) {
+		e, ok := <-events
+		if !ok {
 			return copyFinishedMsg{}
 		}
-		currentOp := m.ops[m.currentOpIndex]
-		var err error
-		switch currentOp.opType {
-		case "mkdir":
-			destPath := filepath.Join(m.destDir, currentOp.relPath)
-			err = os.MkdirAll(destPath, 0755)
-		case "copy":
-			srcPath := filepath.Join(m.sourceDir, currentOp.relPath)
-			destPath := filepath.Join(m.destDir, currentOp.relPath)
-			err = copyFile(srcPath, destPath)
-		}
-		// Simulate a slight delay.
-		time.Sleep(200 * time.Millisecond)
-		return opProcessedMsg{op: currentOp, err: err}
+		return progressMsg(e)
 	}
 }
 
-// loadTemplates returns a slice of template names from the templates directory.
-func loadTemplates(dir string) ([]string, error) {
-	entries, err := os.ReadDir(dir)
-	if err != nil {
-		return nil, err
+// search runs the query of the active search mode against the index.
+func (m model) search() []utils.Match {
+	if m.searchFiles {
+		return utils.FindInTemplates(m.index, m.searchQuery)
 	}
-	var templates []string
-	for _, entry := range entries {
-		if entry.IsDir() {
-			templates = append(templates, entry.Name())
-		}
-	}
-	return templates, nil
+	return utils.SearchTemplates(m.index, m.searchQuery)
 }
 
 // Command suggestion style (dimmed).
 var commandStyle = lipgloss.NewStyle().Faint(true)
 
 // ----- Bubble Tea Model Methods -----
-func initialModel() model {
-	templates, err := loadTemplates(templateDir)
-	if err != nil || len(templates) == 0 {
-		fmt.Println("Error loading templates or no templates found in", templateDir)
-		os.Exit(1)
+func initialModel(cfg *config.Config) (model, error) {
+	idx, err := utils.LoadIndex(cfg)
+	if err != nil {
+		return model{}, err
+	}
+	if len(idx.Entries) == 0 {
+		return model{}, fmt.Errorf("no templates found in %s", strings.Join(cfg.TemplateDirs, ", "))
 	}
 
 	// Initialize the spinner with the Jump spinner.
@@ -209,27 +221,45 @@ func initialModel() model {
 	spinner.Jump.FPS = 12
 	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFA500")).Margin(0, 0)
 
-	return model{
+	m := model{
 		stage:      stageSelectTemplate,
-		templates:  templates,
+		roots:      cfg.TemplateDirs,
 		spinner:    s,
-		treeDepth:  -1, // unlimited depth by default; can be updated via flag.
+		treeDepth:  cfg.Depth,
+		onCancel:   cfg.OnCancel,
 		searchMode: false,
 		blink:      true,
 		showHelp:   false,
 	}
+	m.setIndex(idx)
+	return m, nil
 }
 
 func (m model) Init() tea.Cmd {
-	// Start the blink ticker.
-	return tea.Tick(500*time.Millisecond, func(t time.Time) tea.Msg { return blinkMsg{} })
+	// Start the blink ticker, render the first preview and watch the template roots.
+	return tea.Batch(
+		tea.Tick(500*time.Millisecond, func(t time.Time) tea.Msg { return blinkMsg{} }),
+		m.loadPreview(),
+		scheduleReload(),
+	)
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
-	// Global: Exit immediately if Ctrl+C is pressed.
+	// Global: Exit immediately if Ctrl+C is pressed. While copying, stop the generation
+	// first and quit once it has cleaned up; a second Ctrl+C quits right away.
 	if keyMsg, ok := msg.(tea.KeyMsg); ok && keyMsg.String() == "ctrl+c" {
+		if m.stage == stageCopying && !m.cancelling {
+			m.cancel()
+			m.cancelling = true
+			m.currentLog = "Cancelling..."
+			return m, nil
+		}
+		if m.cancel != nil {
+			// Stops planning, which writes nothing, or a generation already being cancelled.
+			m.cancel()
+		}
 		return m, tea.Quit
 	}
 
@@ -240,11 +270,44 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		l := m.layout(m.instructions())
+		if m.viewer != nil {
+			m.viewer.resize(l.viewerWidth, l.viewerHeight)
+		}
+		if pane, ok := m.preview(); ok && l.previewHeight > 0 {
+			pane.move(0, l.previewHeight)
+		}
 	case blinkMsg:
 		m.blink = !m.blink
 		cmds = append(cmds, tea.Tick(500*time.Millisecond, func(t time.Time) tea.Msg {
 			return blinkMsg{}
 		}))
+	case treeLoadedMsg:
+		// Trees rendered before a reload may be out of date.
+		if msg.cache == m.previews {
+			m.previews.trees[msg.key] = msg.pane
+			delete(m.previews.loading, msg.key)
+		}
+	case fileLoadedMsg:
+		if m.stage == stageSelectTemplate && m.treeFocused {
+			m.viewer = msg.viewer
+		}
+	case reloadTickMsg:
+		// Once a template is selected the list is gone, and so is the need to reload it.
+		if m.stage == stageSelectTemplate {
+			cmds = append(cmds, reloadIndex(m.index, m.roots))
+		}
+	case indexReloadedMsg:
+		if msg.err != nil {
+			slog.Warn("could not reload templates", "error", msg.err)
+		}
+		if msg.changed && m.stage == stageSelectTemplate {
+			slog.Info("templates changed on disk; reloaded", "templates", len(msg.index.Entries))
+			m.setIndex(msg.index)
+		}
+		cmds = append(cmds, scheduleReload())
 
 	// ----- Stage 0: Template Selection -----
 	case tea.KeyMsg:
@@ -260,7 +323,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case tea.KeyEnter:
 				// If there are any suggestions, select the current suggestion and move to the next stage.
 				if len(m.searchResults) > 0 {
-					selection := m.searchResults[m.searchCursor]
+					selection := m.searchResults[m.searchCursor].Name
 					// Find the index of the selection in the full list.
 					for i, tmpl := range m.templates {
 						if tmpl == selection {
@@ -268,7 +331,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 							break
 						}
 					}
-					m.sourceDir = filepath.Join(templateDir, selection)
+					m.sourceDir = m.templateDirs[selection]
 					m.stage = stageProjectName
 				}
 				m.searchMode = false
@@ -280,10 +343,15 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.searchQuery = m.searchQuery[:len(m.searchQuery)-1]
 				}
 				// Recalculate search results.
-				m.searchResults = utils.FilterTemplates(m.templates, m.searchQuery)
+				m.searchResults = m.search()
 				if m.searchCursor >= len(m.searchResults) && len(m.searchResults) > 0 {
 					m.searchCursor = len(m.searchResults) - 1
 				}
+			case tea.KeyTab:
+				// Switch between searching names and searching files.
+				m.searchFiles = !m.searchFiles
+				m.searchResults = m.search()
+				m.searchCursor = 0
 			case tea.KeyUp, tea.KeyShiftUp:
 				if m.searchCursor > 0 {
 					m.searchCursor--
@@ -295,20 +363,73 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			default:
 				// Append any other character to the search query.
 				m.searchQuery += msg.String()
-				m.searchResults = utils.FilterTemplates(m.templates, m.searchQuery)
+				m.searchResults = m.search()
 				m.searchCursor = 0
 			}
+			return m, tea.Batch(append(cmds, m.loadPreview())...)
+		}
+
+		// Keys of the file viewer.
+		if m.stage == stageSelectTemplate && m.viewer != nil {
+			closed, cmd := m.viewer.update(msg)
+			if closed {
+				m.viewer = nil
+			}
+			return m, tea.Batch(append(cmds, cmd)...)
+		}
+
+		// Keys of the focused preview pane.
+		if m.stage == stageSelectTemplate && m.treeFocused {
+			l := m.layout(m.instructions())
+			pane, ok := m.preview()
+			if !ok || l.previewHeight == 0 {
+				m.treeFocused = false
+				return m, tea.Batch(cmds...)
+			}
+			height := l.previewHeight
+			switch msg.String() {
+			case "tab", "esc":
+				m.treeFocused = false
+			case "up", "k":
+				pane.move(-1, height)
+			case "down", "j":
+				pane.move(1, height)
+			case "pgup":
+				pane.move(-(height - 1), height)
+			case "pgdown":
+				pane.move(height-1, height)
+			case "enter", " ":
+				// Directories open and close; files open in the viewer.
+				row, ok := pane.selected()
+				if e, found := m.index.Lookup(m.highlighted()); ok && found && !row.Error && row.Node.Kind != tree.Dir {
+					cmds = append(cmds, openFile(e, row.Path, l.viewerWidth, l.viewerHeight))
+				} else {
+					pane.toggle(height)
+				}
+			case "right", "l":
+				pane.setOpen(true, height)
+			case "left", "h":
+				pane.setOpen(false, height)
+			case "q":
+				return m, tea.Quit
+			}
 			return m, tea.Batch(cmds...)
 		}
 
 		// Normal key handling (outside of search mode)
 		if m.stage == stageSelectTemplate {
 			switch msg.String() {
-			case "/":
-				// Enter search mode.
+			case "tab":
+				// Move into the preview pane, once it is there and has room.
+				if _, ok := m.preview(); ok && m.layout(m.instructions()).previewHeight > 0 {
+					m.treeFocused = true
+				}
+			case "/", "f":
+				// Enter search mode, over names or over files.
 				m.searchMode = true
+				m.searchFiles = msg.String() == "f"
 				m.searchQuery = ""
-				m.searchResults = m.templates // show all initially
+				m.searchResults = m.search() // show all initially
 				m.searchCursor = 0
 			case "up", "k":
 				if m.cursor > 0 {
@@ -319,9 +440,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.cursor++
 				}
 			case "enter":
+				if len(m.templates) == 0 {
+					break
+				}
 				// When a template is selected, set the source directory.
 				selectedTemplate := m.templates[m.cursor]
-				m.sourceDir = filepath.Join(templateDir, selectedTemplate)
+				m.sourceDir = m.templateDirs[selectedTemplate]
 				// Transition to project name input.
 				m.stage = stageProjectName
 			case "q":
@@ -341,55 +465,81 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.err = fmt.Errorf("Error getting CWD: %v", err)
 					return m, tea.Quit
 				}
-				m.destDir = filepath.Join(cwd, m.projectName)
-				if err := os.Mkdir(m.destDir, 0755); err != nil {
-					m.err = fmt.Errorf("Error creating project directory: %v", err)
-					return m, tea.Quit
-				}
-				// Build copy operations.
-				ops, err := buildOps(m.sourceDir)
+				// Reject the name in place so it can be corrected.
+				m.destDir, err = projectDir(cwd, m.projectName)
 				if err != nil {
-					m.err = fmt.Errorf("Error building copy operations: %v", err)
-					return m, tea.Quit
+					m.inputErr = err
+					return m, nil
 				}
-				m.ops = ops
-				m.currentOpIndex = 0
-				m.currentLog = ""
-				m.stage = stageCopying
-				// Begin processing copy operations and start spinner ticking.
-				cmds = append(cmds, nextOpCmd(m), m.spinner.Tick)
+				// Plan in the background with the run's context, so Ctrl+C stops a long walk.
+				m.ctx, m.cancel = context.WithCancel(context.Background())
+				m.stage = stagePlanning
+				return m, tea.Batch(planProject(m.ctx, m.sourceDir, m.destDir, generator.ProjectVars(m.projectName)), m.spinner.Tick)
 			case tea.KeyBackspace:
 				if len(m.inputBuffer) > 0 {
 					m.inputBuffer = m.inputBuffer[:len(m.inputBuffer)-1]
 				}
+				m.inputErr = nil
 			default:
 				// Append typed characters.
 				m.inputBuffer += msg.String()
+				m.inputErr = nil
 			}
+		} else if m.stage == stageConfirmHooks {
+			switch msg.String() {
+			case "y":
+				if err := trustHooks(m.plan); err != nil {
+					m.err = fmt.Errorf("Error recording approved hooks: %v", err)
+					return m, tea.Quit
+				}
+			case "n", "esc":
+				m.plan.Hooks = nil
+			default:
+				return m, nil
+			}
+			plan := m.plan
+			m.plan = nil
+			return m.startCopying(plan)
 		}
 
+	case planReadyMsg:
+		if msg.err != nil {
+			m.cancel()
+			m.err = fmt.Errorf("Error planning the project: %v", msg.err)
+			return m, tea.Quit
+		}
+		if !msg.trusted {
+			// Show the hooks and wait for the user to approve or skip them.
+			m.plan = msg.plan
+			m.stage = stageConfirmHooks
+			return m, nil
+		}
+		return m.startCopying(msg.plan)
+
 	// ----- Stage 2: Copying Process -----
 	case spinner.TickMsg:
 		var cmd tea.Cmd
 		m.spinner, cmd = m.spinner.Update(msg)
 		cmds = append(cmds, cmd)
-	case opProcessedMsg:
-		// Update the single log line.
-		if msg.op.opType == "mkdir" {
-			m.currentLog = fmt.Sprintf("Created directory: %s", msg.op.relPath)
-		} else {
-			m.currentLog = fmt.Sprintf("Copied file: %s", msg.op.relPath)
-		}
-		if msg.err != nil {
-			m.currentLog += fmt.Sprintf(" [Error: %v]", msg.err)
+	case progressMsg:
+		e := generator.Event(msg)
+		switch e.Type {
+		case generator.EventPlan:
+			m.opsTotal = e.Ops + e.Hooks
+		case generator.EventOpDone:
+			m.opsDone++
+		case generator.EventError:
+			m.cancel()
+			m.err = resumeHint(errors.New(e.Error), e.Resumable, m.destDir)
+			return m, tea.Quit
 		}
-		m.currentOpIndex++
-		if m.currentOpIndex < len(m.ops) {
-			cmds = append(cmds, nextOpCmd(m))
-		} else {
-			cmds = append(cmds, func() tea.Msg { return copyFinishedMsg{} })
+		// Update the single log line.
+		if msg := e.Message(); msg != "" && !m.cancelling {
+			m.currentLog = msg
 		}
+		cmds = append(cmds, waitForEvent(m.events))
 	case copyFinishedMsg:
+		m.cancel()
 		m.currentLog = "Project " + "\"" + m.projectName + "\"" + " created successfully!"
 		m.stage = stageDone
 	}
@@ -399,6 +549,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, tea.Quit
 	}
 
+	// Render the tree of a newly highlighted template in the background.
+	cmds = append(cmds, m.loadPreview())
+
 	return m, tea.Batch(cmds...)
 }
 
@@ -418,6 +571,16 @@ func (m model) View() string {
 
 	switch m.stage {
 	case stageSelectTemplate:
+		if m.viewer != nil {
+			body = m.viewer.render()
+			break
+		}
+		instructions := m.instructions()
+		l := m.layout(instructions)
+		panelStyle := style.LeftPanelStyle.Width(l.listWidth).Height(l.listHeight)
+		// Room for a name in the panel, past its padding, the cursor and the item margin.
+		room := l.listWidth - panelStyle.GetHorizontalPadding() - 1 - style.ListItemStyle.GetPaddingLeft()
+
 		var leftPanel string
 		// Build left panel contents.
 		if m.searchMode {
@@ -427,40 +590,41 @@ func (m model) View() string {
 			if m.blink {
 				cursor = style.CursorStyle.Render("|")
 			}
-			sb.WriteString("Search: " + m.searchQuery + cursor + "\n\n")
+			prompt := "Search: "
+			if m.searchFiles {
+				prompt = "Find in files: "
+			}
+			sb.WriteString(prompt + m.searchQuery + cursor + "\n\n")
 			// If there are no results, show a message.
 			if len(m.searchResults) == 0 {
 				sb.WriteString("No matching templates")
 			} else {
-				// Show suggestions with dynamic highlighting.
-				for i, tmpl := range m.searchResults {
+				// Show suggestions with dynamic highlighting of the matched characters,
+				// as many as fit below the prompt.
+				start, end := visibleRange(m.searchCursor, len(m.searchResults), l.listHeight-2)
+				for i := start; i < end; i++ {
+					match := m.searchResults[i]
 					curs := lipgloss.NewStyle().Foreground(lipgloss.Color("#D2F8B0")).Render("⬥")
-					itemStyle := style.ListItemStyle
+					textStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#cbcbcb"))
 					if m.searchCursor == i {
 						curs = "⬥"
-						itemStyle = lipgloss.NewStyle().
-							Foreground(lipgloss.Color("#A6E3A1")).
-							MarginLeft(2).Bold(true)
+						textStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#A6E3A1")).Bold(true)
+					}
+					name := utils.HighlightMatch(match.Name, match.Positions, textStyle, style.MatchStyle)
+					if match.Detail != "" {
+						// Say why it matched, in what room the panel has left.
+						name += " " + commandStyle.Render(truncate(match.Detail, room-lipgloss.Width(match.Name)-1))
 					}
-					sb.WriteString(fmt.Sprintf("%s%s\n", curs, itemStyle.Render(tmpl)))
+					sb.WriteString(fmt.Sprintf("%s%s\n", curs, style.ListItemStyle.Render(name)))
 				}
 			}
-			leftPanel = style.LeftPanelStyle.Render(sb.String())
+			leftPanel = panelStyle.Render(strings.TrimSuffix(sb.String(), "\n"))
 		} else {
-			// Normal list view.
-			visibleLines := 7 // Adjust based on space after prompt/instructions.
-			start := m.cursor - visibleLines/2
-			if start < 0 {
-				start = 0
-			}
-			end := start + visibleLines
-			if end > len(m.templates) {
-				end = len(m.templates)
-				start = max(0, end-visibleLines)
-			}
+			// Normal list view, as many templates as fit in the panel.
+			start, end := visibleRange(m.cursor, len(m.templates), l.listHeight)
 			var listBuilder strings.Builder
 			for i := start; i < end; i++ {
-				tmpl := m.templates[i]
+				tmpl := truncate(m.templates[i], room)
 				curs := "⬦"
 				itemStyle := style.ListItemStyle
 				if m.cursor == i {
@@ -471,43 +635,34 @@ func (m model) View() string {
 				}
 				listBuilder.WriteString(fmt.Sprintf("%s%s\n", curs, itemStyle.Render(tmpl)))
 			}
-			leftPanel = style.LeftPanelStyle.Render(listBuilder.String())
-		}
-
-		// Append fixed command instructions below the left panel.
-		var instructions string
-		if m.searchMode {
-			instructions = " Navigate : ↑/↓ or j/k\n Select   : Enter\n ESC      : Cancel Search\n Exit     : ctrl+c"
-		} else if m.showHelp {
-			instructions = "Navigate: ↑/↓ or j/k\n" +
-				"Select  : Enter\n" +
-				"Search  : /\n" +
-				"Help    : ?\n" +
-				"Exit    : ctrl+c"
-		} else {
-			instructions = lipgloss.NewStyle().
-				// Border(lipgloss.NormalBorder()).
-				PaddingLeft(5).PaddingRight(6).
-				PaddingTop(1).PaddingBottom(1).
-				Render("/ find • q quit • ? help")
+			if len(m.templates) == 0 {
+				listBuilder.WriteString("No templates found in " + strings.Join(m.roots, ", "))
+			}
+			leftPanel = panelStyle.Render(strings.TrimSuffix(listBuilder.String(), "\n"))
 		}
 
-		instructions = commandStyle.Render(instructions)
+		// Append command instructions below the left panel.
 		leftContent := lipgloss.JoinVertical(lipgloss.Left, leftPanel, instructions)
+		if l.previewHeight == 0 {
+			body = leftContent
+			break
+		}
 
 		// For the right panel, show the tree of the currently highlighted template.
-		var selectedTemplate string
-		if m.searchMode && len(m.searchResults) > 0 {
-			selectedTemplate = m.searchResults[m.searchCursor]
-		} else {
-			selectedTemplate = m.templates[m.cursor]
+		var rightContent string
+		if pane, ok := m.preview(); ok {
+			rightContent = pane.view(l.previewWidth, l.previewHeight, m.treeFocused)
+		} else if m.highlighted() != "" {
+			rightContent = commandStyle.Render(truncate("Loading "+m.highlighted()+"...", l.previewWidth))
 		}
-		templatePath := filepath.Join(templateDir, selectedTemplate)
-		rightContent := utils.GetFileTree(templatePath, m.treeDepth)
-		rightPanel := style.RightPanelStyle.Render(rightContent)
+		rightPanel := style.RightPanelStyle.Height(l.previewHeight).Render(rightContent)
 
-		// Horizontally join the fixed left content with the right panel.
-		body = lipgloss.JoinHorizontal(lipgloss.Top, leftContent, rightPanel)
+		// Put the preview next to the list, or below it on narrow terminals.
+		if l.single {
+			body = lipgloss.JoinVertical(lipgloss.Left, leftContent, rightPanel)
+		} else {
+			body = lipgloss.JoinHorizontal(lipgloss.Top, leftContent, rightPanel)
+		}
 
 	case stageProjectName:
 		// Show the project name prompt with blinking cursor.
@@ -516,40 +671,150 @@ func (m model) View() string {
 			cursor = style.CursorStyle.Render("|")
 		}
 		body = fmt.Sprintf("Enter project name: %s%s\n\nPress Ctrl+C to exit at any point.", m.inputBuffer, cursor)
+		if m.inputErr != nil {
+			body += "\n\n" + style.ErrorStyle.Render(m.inputErr.Error())
+		}
+
+	case stagePlanning:
+		body = fmt.Sprintf("%s Planning %s...\n\nPress Ctrl+C to exit at any point.", m.spinner.View(), m.projectName)
+
+	case stageConfirmHooks:
+		// List the hooks so they can be reviewed before anything runs.
+		body = "The template runs these commands in the new project:\n\n"
+		for _, hook := range hookCommands(m.plan) {
+			body += "  " + hook + "\n"
+		}
+		body += "\nRun them, now and in later runs of this template?\n" +
+			"y: run and remember    n/Esc: skip them\n\nPress Ctrl+C to exit at any point."
 
 	case stageCopying:
 		// Render a single log line with the spinner.
-		body = fmt.Sprintf("%s %s\n\nPress Ctrl+C to exit at any point.", m.spinner.View(), m.currentLog)
+		body = fmt.Sprintf("%s [%d/%d] %s\n\nPress Ctrl+C to exit at any point.", m.spinner.View(), m.opsDone, m.opsTotal, m.currentLog)
 
 	case stageDone:
 		body = fmt.Sprint("Done")
 	}
 
-	return style.DocStyle.Render(fmt.Sprintf("%s\n%s\n", style.HeaderStyle.Render("Template Manager ⚡"), body))
+	return style.DocStyle.Render(fmt.Sprintf("%s\n%s\n", style.HeaderStyle.Render(headerTitle), body))
+}
+
+// instructions returns the key instructions shown below the template list.
+func (m model) instructions() string {
+	var instructions string
+	if m.searchMode {
+		instructions = " Navigate : ↑/↓ or j/k\n Select   : Enter\n Tab      : Names/Files\n ESC      : Cancel Search\n Exit     : ctrl+c"
+	} else if m.treeFocused {
+		instructions = " Navigate : ↑/↓ or j/k\n Open     : Enter/Space\n Back     : Tab/Esc\n Exit     : ctrl+c"
+	} else if m.showHelp {
+		instructions = "Navigate: ↑/↓ or j/k\n" +
+			"Select  : Enter\n" +
+			"Search  : /\n" +
+			"Files   : f\n" +
+			"Tree    : Tab\n" +
+			"Help    : ?\n" +
+			"Exit    : ctrl+c"
+	} else {
+		instructions = lipgloss.NewStyle().
+			PaddingLeft(1).
+			PaddingTop(1).PaddingBottom(1).
+			Render("/ find • f files • q quit • ? help")
+	}
+	return commandStyle.Render(instructions)
+}
+
+// visibleRange returns the part of a list of n items to show in lines lines, keeping the
+// cursor in the middle where possible.
+func visibleRange(cursor, n, lines int) (start, end int) {
+	lines = max(lines, 1)
+	start = max(cursor-lines/2, 0)
+	end = start + lines
+	if end > n {
+		end = n
+		start = max(0, end-lines)
+	}
+	return start, end
 }
 
 // ----- Main -----
 func main() {
-	// Parse flags and commands correctly
-	cf := utils.ParseFlags()
-	cp := utils.ParseCommands()
+	utils.Register(newCommand, resumeCommand)
+
+	// Merge defaults, config file, environment and flags
+	cfg, err := config.Load(utils.HoistGlobalFlags(os.Args[1:]))
+	if err != nil {
+		fmt.Println("Error loading configuration:", err)
+		fmt.Println("Use '--help' to see available flags.")
+		os.Exit(2)
+	}
+
+	// Without a terminal (pipes, CI runners, TERM=dumb) the TUI cannot run; prompt line by line instead.
+	launchUI := cfg.Command == "" && !cfg.Help
+	useTUI := launchUI && (cfg.UI == "tui" || cfg.UI == "auto" && hasTerminal())
+
+	// Headless commands log to stderr; the TUI owns the screen, so it logs to a file.
+	logFile := ""
+	if useTUI {
+		if logFile, err = paths.LogFile(); err != nil {
+			fmt.Println("Error locating log file:", err)
+			os.Exit(1)
+		}
+	}
+	closeLog, err := logging.Setup(logging.Options{Level: cfg.LogLevel, Verbose: cfg.Verbose, File: logFile})
+	if err != nil {
+		fmt.Println("Error setting up logging:", err)
+		os.Exit(1)
+	}
+	defer closeLog()
+	// os.Exit skips deferred calls, so exit through this to close the log file first.
+	// Commands exiting inside utils.Execute log to stderr, which needs no closing.
+	exit := func(code int) {
+		closeLog()
+		os.Exit(code)
+	}
+	slog.Debug("configuration loaded", "config_file", cfg.ConfigFile, "template_dirs", cfg.TemplateDirs, "command", cfg.Command)
+
+	if len(cfg.FileErrors) > 0 && cfg.Command != "config" {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring %d invalid settings in %s; run 'config validate' for details\n", len(cfg.FileErrors), cfg.ConfigFile)
+	}
 
 	// Execute commands (if any)
-	utils.Execute(cf, cp)
+	utils.Execute(cfg)
 
 	// If a command was executed, exit before launching UI
-	if cp.Command != "" {
-		os.Exit(0)
+	if cfg.Command != "" {
+		exit(0)
+	}
+
+	if !useTUI {
+		if err := runPlain(cfg, os.Stdin, os.Stdout); err != nil {
+			fmt.Println("Error:", err)
+			exit(1)
+		}
+		return
 	}
 
 	// Initialize UI model
-	m := initialModel()
-	m.treeDepth = cf.Depth
+	m, err := initialModel(cfg)
+	if err != nil {
+		fmt.Println("Error loading templates:", err)
+		exit(1)
+	}
 
 	// Run Bubble Tea program
 	p := tea.NewProgram(m, tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
+	final, err := p.Run()
+	if err != nil {
 		fmt.Println("Error running program:", err)
-		os.Exit(1)
+		exit(1)
+	}
+	// The alt screen is gone with the program; repeat how the run ended on the normal screen.
+	if m, ok := final.(model); ok {
+		switch {
+		case m.err != nil:
+			fmt.Println("Error:", m.err)
+			exit(1)
+		case m.stage == stageDone:
+			fmt.Println(m.currentLog)
+		}
 	}
 }
@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestVisibleRange(t *testing.T) {
+	tests := []struct {
+		name               string
+		cursor, n, lines   int
+		wantStart, wantEnd int
+	}{
+		{"cursor at the top", 0, 10, 5, 0, 5},
+		{"cursor centred", 5, 10, 5, 3, 8},
+		{"cursor at the bottom", 9, 10, 5, 5, 10},
+		{"everything fits", 2, 3, 5, 0, 3},
+		{"empty list", 0, 0, 5, 0, 0},
+		{"no room shows the cursor", 4, 10, 0, 4, 5},
+		{"one line", 7, 10, 1, 7, 8},
+	}
+	for _, tt := range tests {
+		start, end := visibleRange(tt.cursor, tt.n, tt.lines)
+		if start != tt.wantStart || end != tt.wantEnd {
+			t.Errorf("%s: visibleRange(%d, %d, %d) = %d, %d; want %d, %d",
+				tt.name, tt.cursor, tt.n, tt.lines, start, end, tt.wantStart, tt.wantEnd)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+
+	"open-template/generator"
+	"open-template/internal/config"
+	"open-template/utils"
+)
+
+// progressFormats are the accepted values of --progress.
+var progressFormats = []string{"text", "jsonl"}
+
+// newCommand generates a project without the TUI, for scripts and CI.
+var newCommand = &utils.Command{
+	Name:    "new",
+	Aliases: []string{"generate"},
+	Usage:   "<template> <project-name> [flags]",
+	Short:   "Generate a project from a template without the TUI",
+	Long: "Creates <project-name> in the current directory (or --dir) from the template.\n" +
+		"--on-conflict decides what happens to files that already exist: fail, skip or overwrite.\n" +
+		"Variables declared in template.json take their default unless set with --var name=value.\n" +
+		"The template's hooks run in the project directory afterwards once you approved them:\n" +
+		"--run-hooks runs them and remembers the approval until they change; --no-hooks skips them.\n" +
+		"--progress jsonl writes one JSON event per line (plan, op_start, op_done, hook_output,\n" +
+		"error, done) to stderr, or to the file descriptor given by --progress-fd.",
+	Examples: []string{
+		"new go-service billing",
+		"new go-service billing --dir ~/src --on-conflict skip",
+		"new go-service billing --var Author='Jane Doe' --var Port=8080",
+		"new go-service billing --run-hooks",
+		"new go-service billing --progress jsonl --progress-fd 3 3>progress.jsonl",
+	},
+	Complete:   utils.CompleteTemplateName,
+	FlagValues: map[string][]string{"on-conflict": conflictPolicyNames(), "progress": progressFormats},
+	Setup: func(fs *flag.FlagSet, cfg *config.Config) func([]string) error {
+		dir := fs.String("dir", ".", "Directory to create the project in")
+		onConflict := fs.String("on-conflict", string(generator.Fail), "What to do with existing files: "+strings.Join(conflictPolicyNames(), ", "))
+		noHooks := fs.Bool("no-hooks", false, "Do not run the template's hooks")
+		runHooks := fs.Bool("run-hooks", false, "Run the template's hooks and remember them as approved")
+		progressFormat := fs.String("progress", "text", "Progress output: "+strings.Join(progressFormats, ", "))
+		progressFD := fs.Int("progress-fd", 2, "File descriptor receiving --progress jsonl events")
+		vars := map[string]string{}
+		fs.Func("var", "Set a template variable, as name=value (repeatable)", func(s string) error {
+			name, value, ok := strings.Cut(s, "=")
+			switch {
+			case !ok || name == "":
+				return fmt.Errorf("want name=value, got %q", s)
+			case name == generator.ProjectNameVar:
+				return fmt.Errorf("%s is the <project-name> argument", name)
+			}
+			vars[name] = value
+			return nil
+		})
+
+		return func(args []string) error {
+			if len(args) != 2 {
+				return utils.ErrUsage
+			}
+			policy := generator.ConflictPolicy(*onConflict)
+			if !slices.Contains(generator.ConflictPolicies, policy) {
+				return fmt.Errorf("%w: --on-conflict must be one of %s", utils.ErrUsage, strings.Join(conflictPolicyNames(), ", "))
+			}
+			if *noHooks && *runHooks {
+				return fmt.Errorf("%w: --no-hooks and --run-hooks exclude each other", utils.ErrUsage)
+			}
+			observer, err := progressObserver(*progressFormat, *progressFD)
+			if err != nil {
+				return err
+			}
+			tmpl, ok := utils.FindTemplate(cfg.TemplateDirs, args[0])
+			if !ok {
+				return fmt.Errorf("no template named %q", args[0])
+			}
+			projectName := strings.TrimSpace(args[1])
+
+			destDir, err := projectDir(*dir, projectName)
+			if errors.Is(err, os.ErrExist) && policy != generator.Fail {
+				err = nil
+			}
+			if err != nil {
+				return fmt.Errorf("%w; use --on-conflict skip or overwrite to generate into it", err)
+			}
+			// Interrupting stops the run; on_cancel decides whether its files are removed.
+			ctx, stop := interruptContext()
+			defer stop()
+			values := generator.ProjectVars(projectName)
+			for name, value := range vars {
+				values[name] = value
+			}
+			plan, err := generator.NewPlan(ctx, tmpl.Dir(), destDir, values)
+			if err != nil {
+				return fmt.Errorf("planning %s: %w", tmpl.Name, err)
+			}
+			opts := generator.Options{
+				OnConflict: policy,
+				OnCancel:   generator.CancelPolicy(cfg.OnCancel),
+				Observer:   observer,
+				Journal:    true,
+				Approve:    hookApproval(*noHooks, *runHooks, os.Stderr),
+			}
+			if err := generator.Execute(ctx, plan, opts); err != nil {
+				return resumeHint(err, errors.Is(err, generator.ErrResumable), destDir)
+			}
+			if *progressFormat == "text" {
+				fmt.Printf("Project %q created successfully!\n", projectName)
+			}
+			return nil
+		}
+	},
+}
+
+// progressObserver returns the observer printing progress in the --progress format,
+// to stdout for text and to the file descriptor fd, stderr by default, for jsonl.
+func progressObserver(format string, fd int) (generator.Observer, error) {
+	switch format {
+	case "text":
+		return generator.Lines(os.Stdout), nil
+	case "jsonl":
+		out := os.NewFile(uintptr(fd), "progress")
+		if out == nil || fd < 1 {
+			return nil, fmt.Errorf("%w: invalid --progress-fd %d", utils.ErrUsage, fd)
+		}
+		if _, err := out.Stat(); err != nil {
+			return nil, fmt.Errorf("--progress-fd %d is not open: %w", fd, err)
+		}
+		return generator.JSONL(out), nil
+	}
+	return nil, fmt.Errorf("%w: --progress must be one of %s", utils.ErrUsage, strings.Join(progressFormats, ", "))
+}
+
+// interruptContext returns a context cancelled by Ctrl+C or SIGTERM.
+func interruptContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// resumeHint points to the resume command when the failed run was interrupted and can be
+// finished, as told by resumable.
+func resumeHint(err error, resumable bool, destDir string) error {
+	if !resumable {
+		return err
+	}
+	return fmt.Errorf("%w\nRun 'open-template resume %s' to finish it", err, destDir)
+}
+
+// validateProjectName rejects names that cannot be used as a directory in the current one.
+func validateProjectName(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("project name is empty")
+	case name == "." || name == "..":
+		return fmt.Errorf("invalid project name %q", name)
+	case strings.ContainsAny(name, `/\`):
+		return fmt.Errorf("project name %q must not contain path separators", name)
+	}
+	return nil
+}
+
+// projectDir validates the project name and returns the project directory inside parent.
+// The returned error wraps os.ErrExist if the directory already exists. The TUI, the
+// prompt flow and the new command share it so they accept the same names.
+func projectDir(parent, name string) (string, error) {
+	if err := validateProjectName(name); err != nil {
+		return "", err
+	}
+	dir := filepath.Join(parent, name)
+	if _, err := os.Lstat(dir); err == nil {
+		return dir, fmt.Errorf("%s: %w", dir, os.ErrExist)
+	}
+	return dir, nil
+}
+
+// conflictPolicyNames returns the accepted values of --on-conflict.
+func conflictPolicyNames() []string {
+	names := make([]string, len(generator.ConflictPolicies))
+	for i, p := range generator.ConflictPolicies {
+		names[i] = string(p)
+	}
+	return names
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"open-template/generator"
+	"open-template/internal/config"
+	"open-template/internal/index"
+	"open-template/utils"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// errNoInput is returned when the prompt flow runs out of input, e.g. under a CI runner.
+var errNoInput = errors.New("no input available; without a terminal, run 'open-template new <template> <project-name>' instead")
+
+// hasTerminal reports whether the TUI can own the screen: stdin and stdout are terminals
+// and the terminal is capable of it.
+func hasTerminal() bool {
+	return term.IsTerminal(os.Stdin.Fd()) && term.IsTerminal(os.Stdout.Fd()) && os.Getenv("TERM") != "dumb"
+}
+
+// runPlain walks through the same stages as the TUI with line-based prompts:
+// select a template, enter the project name, approve its hooks, copy, done.
+func runPlain(cfg *config.Config, in io.Reader, out io.Writer) error {
+	idx, err := utils.LoadIndex(cfg)
+	if err != nil {
+		return err
+	}
+	templates := idx.Entries
+	if len(templates) == 0 {
+		return fmt.Errorf("no templates found in %s", strings.Join(cfg.TemplateDirs, ", "))
+	}
+
+	reader := bufio.NewReader(in)
+	prompt := func(question string) (string, error) {
+		fmt.Fprint(out, question)
+		line, err := reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			fmt.Fprintln(out)
+			return "", errNoInput
+		}
+		return strings.TrimSpace(line), nil
+	}
+
+	// Stage 0: Template selection, by number or name.
+	for i, t := range templates {
+		fmt.Fprintf(out, "%3d) %s\n", i+1, t.Name)
+	}
+	var selected *index.Entry
+	for selected == nil {
+		answer, err := prompt(fmt.Sprintf("Template [1-%d or name]: ", len(templates)))
+		if err != nil {
+			return err
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(templates) {
+			selected = templates[n-1]
+			continue
+		}
+		for _, t := range templates {
+			if t.Name == answer {
+				selected = t
+			}
+		}
+		if selected == nil && answer != "" {
+			fmt.Fprintf(out, "No template %q\n", answer)
+		}
+	}
+
+	// Stage 1: Project name input, validated like the TUI.
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	var projectName, destDir string
+	for destDir == "" {
+		if projectName, err = prompt("Project name: "); err != nil {
+			return err
+		}
+		if projectName == "" {
+			continue
+		}
+		if destDir, err = projectDir(cwd, projectName); err != nil {
+			fmt.Fprintln(out, err)
+			destDir = ""
+		}
+	}
+
+	// Stage 2: Copying process, one log line per operation.
+	ctx, stop := interruptContext()
+	defer stop()
+	plan, err := generator.NewPlan(ctx, selected.Dir(), destDir, generator.ProjectVars(projectName))
+	if err != nil {
+		return err
+	}
+	trusted, err := hooksTrusted(plan)
+	if err != nil {
+		return err
+	}
+	if !trusted {
+		fmt.Fprintln(out, "The template runs these commands in the new project:")
+		for _, hook := range hookCommands(plan) {
+			fmt.Fprintf(out, "  %s\n", hook)
+		}
+		answer, err := prompt("Run them, now and in later runs of this template? [y/N]: ")
+		if err != nil {
+			return err
+		}
+		if strings.EqualFold(answer, "y") || strings.EqualFold(answer, "yes") {
+			if err := trustHooks(plan); err != nil {
+				return err
+			}
+		} else {
+			plan.Hooks = nil
+		}
+	}
+	opts := generator.Options{OnCancel: generator.CancelPolicy(cfg.OnCancel), Observer: generator.Lines(out), Journal: true}
+	if err := generator.Execute(ctx, plan, opts); err != nil {
+		return resumeHint(err, errors.Is(err, generator.ErrResumable), destDir)
+	}
+
+	// Stage 3: Done.
+	fmt.Fprintf(out, "Project %q created successfully!\n", projectName)
+	return nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"open-template/internal/config"
+)
+
+// plainEnv creates two templates, the second with a hook, and runs the test in an empty
+// working directory with its own cache and state directories. It returns the config.
+func plainEnv(t *testing.T) *config.Config {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		"api/README.md.tmpl": "# {{.ProjectName}}\n",
+		"web/template.json":  `{"name": "web", "hooks": ["echo ran > hook.txt"]}`,
+		"web/index.html":     "<h1>{{.ProjectName}}</h1>\n",
+	}
+	for rel, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return &config.Config{TemplateDirs: []string{root}, OnCancel: "rollback"}
+}
+
+func TestRunPlain(t *testing.T) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	tests := []struct {
+		name     string
+		input    string
+		err      error
+		want     map[string]string // generated files, relative to the working directory
+		noFile   string            // a file that must not be generated
+		wantText []string          // printed
+	}{
+		{
+			name:     "by number",
+			input:    "1\nbilling\n",
+			want:     map[string]string{"billing/README.md": "# billing\n"},
+			wantText: []string{"  1) api", "  2) web", `Project "billing" created successfully!`},
+		},
+		{
+			name:  "by name",
+			input: "api\nbilling\n",
+			want:  map[string]string{"billing/README.md": "# billing\n"},
+		},
+		{
+			name:     "retry after an invalid name or number",
+			input:    "nope\n7\n\napi\nbilling\n",
+			want:     map[string]string{"billing/README.md": "# billing\n"},
+			wantText: []string{`No template "nope"`, `No template "7"`},
+		},
+		{
+			name:     "hooks approved",
+			input:    "web\nshop\ny\n",
+			want:     map[string]string{"shop/index.html": "<h1>shop</h1>\n", "shop/hook.txt": "ran\n"},
+			wantText: []string{"The template runs these commands in the new project:", "  echo ran > hook.txt"},
+		},
+		{
+			name:   "hooks declined",
+			input:  "web\nshop\nn\n",
+			want:   map[string]string{"shop/index.html": "<h1>shop</h1>\n"},
+			noFile: "shop/hook.txt",
+		},
+		{
+			name:  "no input",
+			input: "",
+			err:   errNoInput,
+		},
+		{
+			name:   "input ends at the project name",
+			input:  "1\n",
+			err:    errNoInput,
+			noFile: "billing",
+		},
+		{
+			name:   "input ends at the hook prompt",
+			input:  "web\nshop\n",
+			err:    errNoInput,
+			noFile: "shop",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := plainEnv(t)
+			var out strings.Builder
+			err := runPlain(cfg, strings.NewReader(tt.input), &out)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("runPlain = %v, want %v; output:\n%s", err, tt.err, out.String())
+			}
+			for rel, want := range tt.want {
+				if data, err := os.ReadFile(filepath.FromSlash(rel)); string(data) != want {
+					t.Errorf("%s = %q, %v; want %q", rel, data, err, want)
+				}
+			}
+			if tt.noFile != "" {
+				if _, err := os.Stat(filepath.FromSlash(tt.noFile)); err == nil {
+					t.Errorf("%s was created", tt.noFile)
+				}
+			}
+			for _, text := range tt.wantText {
+				if !strings.Contains(out.String(), text) {
+					t.Errorf("output does not contain %q:\n%s", text, out.String())
+				}
+			}
+		})
+	}
+}
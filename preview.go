@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"open-template/internal/tree"
+	style "open-template/internal/ui/style"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// treeKey identifies a preview tree.
+type treeKey struct {
+	template string
+	depth    int
+}
+
+// treeLoadedMsg delivers a preview tree built in the background.
+type treeLoadedMsg struct {
+	cache *previewCache // the cache it was built for
+	key   treeKey
+	pane  *treePane
+}
+
+// previewCache memoizes the preview trees, so View never walks them itself, and keeps
+// the directories opened and closed in each. It is shared by the copies of the model
+// bubbletea passes around.
+type previewCache struct {
+	trees   map[treeKey]*treePane
+	loading map[treeKey]bool
+}
+
+func newPreviewCache() *previewCache {
+	return &previewCache{trees: map[treeKey]*treePane{}, loading: map[treeKey]bool{}}
+}
+
+// highlighted returns the name of the template under the cursor, in the list or the search results.
+func (m model) highlighted() string {
+	if m.searchMode {
+		if len(m.searchResults) == 0 {
+			return ""
+		}
+		return m.searchResults[m.searchCursor].Name
+	}
+	if len(m.templates) == 0 {
+		return ""
+	}
+	return m.templates[m.cursor]
+}
+
+// loadPreview returns a command building the tree of the highlighted template, or nil if
+// it is cached or already being built.
+func (m model) loadPreview() tea.Cmd {
+	if m.stage != stageSelectTemplate {
+		return nil
+	}
+	name := m.highlighted()
+	key := treeKey{template: name, depth: m.treeDepth}
+	if name == "" || m.previews.loading[key] {
+		return nil
+	}
+	if _, ok := m.previews.trees[key]; ok {
+		return nil
+	}
+	e, ok := m.index.Lookup(name)
+	if !ok {
+		return nil
+	}
+	cache := m.previews
+	cache.loading[key] = true
+	return func() tea.Msg {
+		// The whole tree is walked, so directories below the depth limit can be opened.
+		root, err := tree.Walk(e.Name, tree.Entry(e), -1)
+		return treeLoadedMsg{cache: cache, key: key, pane: newTreePane(root, err, key.depth)}
+	}
+}
+
+// preview returns the tree of the highlighted template, and false while it is being built.
+func (m model) preview() (*treePane, bool) {
+	pane, ok := m.previews.trees[treeKey{template: m.highlighted(), depth: m.treeDepth}]
+	return pane, ok
+}
+
+// treePane is the preview of a template's tree. It can be focused to move through the
+// entries, open and close directories and scroll.
+type treePane struct {
+	root   *tree.Node
+	err    error
+	open   map[*tree.Node]bool
+	rows   []tree.Row // rows of the open directories
+	cursor int
+	offset int // first row shown
+}
+
+// newTreePane shows the tree root with the directories above maxDepth open, or all of them
+// if maxDepth is negative.
+func newTreePane(root *tree.Node, err error, maxDepth int) *treePane {
+	p := &treePane{root: root, err: err, open: map[*tree.Node]bool{}}
+	if err != nil {
+		return p
+	}
+	var walk func(n *tree.Node, level int)
+	walk = func(n *tree.Node, level int) {
+		for _, child := range n.Children {
+			if child.Kind == tree.Dir && (maxDepth < 0 || level < maxDepth) {
+				p.open[child] = true
+				walk(child, level+1)
+			}
+		}
+	}
+	walk(root, 1)
+	p.layout()
+	return p
+}
+
+// layout lays out the rows of the open directories.
+func (p *treePane) layout() {
+	p.rows = tree.Rows(p.root, func(n *tree.Node) bool { return p.open[n] })
+	p.cursor = min(p.cursor, max(0, len(p.rows)-1))
+}
+
+// move moves the cursor by delta rows, scrolling a pane of height lines to keep it in view.
+func (p *treePane) move(delta, height int) {
+	rows := height - 1 // the last line is the position indicator
+	p.cursor = min(max(p.cursor+delta, 0), max(0, len(p.rows)-1))
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	}
+	if p.cursor >= p.offset+rows {
+		p.offset = p.cursor - rows + 1
+	}
+}
+
+// setOpen opens or closes the directory under the cursor.
+func (p *treePane) setOpen(open bool, height int) {
+	if len(p.rows) == 0 {
+		return
+	}
+	row := p.rows[p.cursor]
+	if row.Error || row.Node.Kind != tree.Dir {
+		return
+	}
+	p.open[row.Node] = open
+	p.layout()
+	p.offset = min(p.offset, max(0, len(p.rows)-(height-1)))
+	p.move(0, height)
+}
+
+// selected returns the row under the cursor.
+func (p *treePane) selected() (tree.Row, bool) {
+	if len(p.rows) == 0 {
+		return tree.Row{}, false
+	}
+	return p.rows[p.cursor], true
+}
+
+// toggle opens the directory under the cursor if it is closed, and closes it otherwise.
+func (p *treePane) toggle(height int) {
+	if len(p.rows) > 0 {
+		p.setOpen(!p.open[p.rows[p.cursor].Node], height)
+	}
+}
+
+// view renders the rows shown in a pane of width columns and height lines, with the cursor
+// when focused. Rows too wide for the pane are cut.
+func (p *treePane) view(width, height int, focused bool) string {
+	if p.err != nil {
+		return style.ErrorStyle.Render(p.err.Error())
+	}
+	if len(p.rows) == 0 {
+		return tree.Empty()
+	}
+	rows := height - 1 // the last line is the position indicator
+	end := min(p.offset+rows, len(p.rows))
+	var sb strings.Builder
+	for i := p.offset; i < end; i++ {
+		row := p.rows[i]
+		line := tree.ColorRow(row, row.Node.Kind == tree.Dir && !p.open[row.Node])
+		if focused {
+			gutter := "  "
+			if i == p.cursor {
+				gutter = lipgloss.NewStyle().Foreground(lipgloss.Color("#D2F8B0")).Render("⬥ ")
+			}
+			line = gutter + line
+		}
+		sb.WriteString(ansi.Truncate(line, width, "…") + "\n")
+	}
+	switch {
+	case focused:
+		sb.WriteString(commandStyle.Render(fmt.Sprintf("%d/%d", p.cursor+1, len(p.rows))))
+	case len(p.rows) > rows:
+		sb.WriteString(commandStyle.Render(fmt.Sprintf("%d-%d of %d", p.offset+1, end, len(p.rows))))
+	}
+	return sb.String()
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"open-template/generator"
+	"open-template/internal/config"
+	"open-template/utils"
+)
+
+// resumeCommand finishes a generation interrupted by Ctrl+C, SIGTERM or a crash.
+var resumeCommand = &utils.Command{
+	Name:  "resume",
+	Usage: "<project-dir> [flags]",
+	Short: "Finish an interrupted project generation",
+	Long: "Generation records each completed step in a journal next to the project directory.\n" +
+		"resume checks the files already there against the journal's checksums, redoes those\n" +
+		"that changed and continues from the first step that did not complete. Hooks run as\n" +
+		"with new: --run-hooks approves them, --no-hooks skips them.",
+	Examples: []string{
+		"resume billing",
+		"resume ~/src/billing --progress jsonl",
+	},
+	FlagValues: map[string][]string{"progress": progressFormats},
+	Setup: func(fs *flag.FlagSet, cfg *config.Config) func([]string) error {
+		progressFormat := fs.String("progress", "text", "Progress output: "+strings.Join(progressFormats, ", "))
+		noHooks := fs.Bool("no-hooks", false, "Do not run the template's hooks")
+		runHooks := fs.Bool("run-hooks", false, "Run the template's hooks and remember them as approved")
+		progressFD := fs.Int("progress-fd", 2, "File descriptor receiving --progress jsonl events")
+
+		return func(args []string) error {
+			if len(args) != 1 {
+				return utils.ErrUsage
+			}
+			if *noHooks && *runHooks {
+				return fmt.Errorf("%w: --no-hooks and --run-hooks exclude each other", utils.ErrUsage)
+			}
+			observer, err := progressObserver(*progressFormat, *progressFD)
+			if err != nil {
+				return err
+			}
+			destDir := args[0]
+			if _, err := os.Stat(generator.JournalPath(destDir)); err != nil {
+				return fmt.Errorf("%s has no interrupted generation to resume", destDir)
+			}
+
+			ctx, stop := interruptContext()
+			defer stop()
+			opts := generator.Options{
+				OnCancel: generator.CancelPolicy(cfg.OnCancel),
+				Observer: observer,
+				Approve:  hookApproval(*noHooks, *runHooks, os.Stderr),
+			}
+			if err := generator.Resume(ctx, destDir, opts); err != nil {
+				return resumeHint(err, errors.Is(err, generator.ErrResumable), destDir)
+			}
+			if *progressFormat == "text" {
+				fmt.Printf("Project in %s completed successfully!\n", destDir)
+			}
+			return nil
+		}
+	},
+}
@@ -0,0 +1,176 @@
+package utils
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"open-template/internal/auth"
+	"open-template/internal/config"
+	"open-template/internal/paths"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// authCommand manages tokens for private template registries.
+var authCommand = &Command{
+	Name:  "auth",
+	Usage: "login <registry-url> [--token-stdin] | logout [registry-url] | whoami [registry-url] | list",
+	Short: "Manage tokens for private template registries",
+	Long: "Tokens are validated against <registry-url>" + auth.WhoAmIPath + " and stored per registry in\n" +
+		"credentials.json in the config directory, readable only by you. sync sends them to matching remotes.",
+	Examples: []string{
+		"auth login https://templates.example.com",
+		"auth login https://templates.example.com --token-stdin < token.txt",
+		"auth whoami",
+		"auth list",
+		"auth logout https://templates.example.com",
+	},
+	Complete: func(cfg *config.Config, args []string) []string {
+		if len(args) == 0 {
+			return []string{"list", "login", "logout", "whoami"}
+		}
+		return nil
+	},
+	Setup: func(fs *flag.FlagSet, cfg *config.Config) func([]string) error {
+		// There is no --token flag: a token in the arguments would show in ps and the shell history.
+		tokenStdin := fs.Bool("token-stdin", false, "Read the token for login from stdin instead of prompting")
+
+		return func(args []string) error {
+			if len(args) == 0 {
+				return ErrUsage
+			}
+			action, positional := args[0], args[1:]
+
+			path, err := paths.CredentialsFile()
+			if err != nil {
+				return err
+			}
+			store, err := auth.Open(path)
+			if err != nil {
+				return err
+			}
+
+			switch action {
+			case "login":
+				if len(positional) != 1 {
+					return ErrUsage
+				}
+				registry, err := auth.Normalize(positional[0])
+				if err != nil {
+					return err
+				}
+				token, err := readToken(registry, *tokenStdin)
+				if err != nil {
+					return err
+				}
+				user, err := auth.WhoAmI(registry, token)
+				if err != nil {
+					return err
+				}
+				if err := store.Set(registry, auth.Credential{Token: token, Username: user}); err != nil {
+					return err
+				}
+				if err := store.Save(); err != nil {
+					return err
+				}
+				fmt.Printf("Logged in to %s as %s\n", registry, user)
+
+			case "logout":
+				registry, err := pickRegistry(store, positional)
+				if err != nil {
+					return err
+				}
+				store.Delete(registry)
+				if err := store.Save(); err != nil {
+					return err
+				}
+				fmt.Printf("Logged out of %s\n", registry)
+
+			case "whoami":
+				registry, err := pickRegistry(store, positional)
+				if err != nil {
+					return err
+				}
+				user, err := auth.WhoAmI(registry, store.Registries[registry].Token)
+				if err != nil {
+					return fmt.Errorf("%s: %w", registry, err)
+				}
+				fmt.Printf("%s: %s\n", registry, user)
+
+			case "list":
+				if len(store.Registries) == 0 {
+					fmt.Println("Not logged in to any registry")
+				}
+				for _, registry := range store.List() {
+					fmt.Printf("%v\t%v\n", commandStyle.MarginLeft(0).Render(registry), descriptionStyle.Render(store.Registries[registry].Username))
+				}
+
+			default:
+				return fmt.Errorf("%w: unknown action %q", ErrUsage, action)
+			}
+			return nil
+		}
+	},
+}
+
+// pickRegistry resolves the registry argument of logout and whoami.
+// Without an argument it picks the only registry logged in to.
+func pickRegistry(store *auth.Store, positional []string) (string, error) {
+	switch {
+	case len(positional) == 1:
+		registry, err := auth.Normalize(positional[0])
+		if err != nil {
+			return "", err
+		}
+		if _, ok := store.Registries[registry]; !ok {
+			return "", fmt.Errorf("not logged in to %s", registry)
+		}
+		return registry, nil
+	case len(positional) > 1:
+		return "", errors.New("expected a single registry URL")
+	}
+
+	registries := store.List()
+	switch len(registries) {
+	case 0:
+		return "", errors.New("not logged in to any registry")
+	case 1:
+		return registries[0], nil
+	}
+	return "", fmt.Errorf("logged in to several registries; name one of: %s", strings.Join(registries, ", "))
+}
+
+// readToken prompts for a token, hiding the input, or reads it from the first line of stdin
+// if fromStdin is set or stdin is not a terminal.
+func readToken(registry string, fromStdin bool) (string, error) {
+	if !fromStdin && term.IsTerminal(os.Stdin.Fd()) {
+		fmt.Printf("Token for %s: ", registry)
+		token, err := term.ReadPassword(os.Stdin.Fd())
+		fmt.Println()
+		return strings.TrimSpace(string(token)), err
+	}
+	token, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", errors.New("no token given on stdin")
+	}
+	return token, nil
+}
+
+// registryToken returns the stored token for a git remote URL, used by sync.
+func registryToken(remoteURL string) string {
+	path, err := paths.CredentialsFile()
+	if err != nil {
+		return ""
+	}
+	store, err := auth.Open(path)
+	if err != nil {
+		return ""
+	}
+	_, c, _ := store.Lookup(remoteURL)
+	return c.Token
+}
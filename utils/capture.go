@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"open-template/internal/capture"
+	"open-template/internal/config"
+)
+
+// captureCommand copies an existing project into a template root as a new template.
+var captureCommand = &Command{
+	Name:  "capture",
+	Usage: "<path> [flags]",
+	Short: "Capture an existing directory as a new template",
+	Long: "Copies the project into a template root, respecting its .gitignore files and skipping .git.\n" +
+		"Occurrences of the project name can be replaced with the {{.ProjectName}} placeholder,\n" +
+		"and a starter template.json manifest listing the variables found is written.",
+	Examples: []string{
+		"capture ./my-service --name go-service",
+		"capture ./my-service --replace-name=false",
+	},
+	Setup: func(fs *flag.FlagSet, cfg *config.Config) func([]string) error {
+		root := fs.String("root", cfg.TemplateDirs[0], "Template root to capture into")
+		name := fs.String("name", "", "Name of the new template (defaults to the directory name)")
+		projectName := fs.String("project-name", "", "Project name to replace with {{.ProjectName}} (defaults to the directory name)")
+		replaceName := fs.Bool("replace-name", false, "Replace the project name with {{.ProjectName}} without asking")
+
+		return func(args []string) error {
+			if len(args) != 1 {
+				return ErrUsage
+			}
+
+			opts := capture.Options{Name: *name, ProjectName: *projectName, ReplaceName: *replaceName}
+			if opts.ProjectName == "" {
+				abs, err := filepath.Abs(args[0])
+				if err != nil {
+					return err
+				}
+				opts.ProjectName = filepath.Base(abs)
+			}
+
+			// Offer the replacement unless --replace-name was given explicitly.
+			asked := false
+			fs.Visit(func(f *flag.Flag) { asked = asked || f.Name == "replace-name" })
+			if !asked {
+				fmt.Printf("Replace occurrences of %q with {{.ProjectName}}? [y/N] ", opts.ProjectName)
+				answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+				answer = strings.ToLower(strings.TrimSpace(answer))
+				opts.ReplaceName = answer == "y" || answer == "yes"
+			}
+
+			res, err := capture.Capture(args[0], *root, opts)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Captured %d files into %s", res.Files, res.Dir)
+			if res.Skipped > 0 {
+				fmt.Printf(" (%d ignored)", res.Skipped)
+			}
+			fmt.Println()
+			if opts.ReplaceName {
+				fmt.Printf("Replaced %d occurrences of %q\n", res.Replacements, opts.ProjectName)
+			}
+			for _, v := range res.Manifest.Variables {
+				fmt.Printf("Variable: %s\n", v.Name)
+			}
+			return nil
+		}
+	},
+}
@@ -1,9 +1,16 @@
 package utils
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"open-template/internal/config"
 
 	"github.com/charmbracelet/lipgloss"
 )
@@ -20,96 +27,270 @@ var (
 
 	descriptionStyle = lipgloss.NewStyle().
 				Foreground(lipgloss.Color("##FFFFF0"))
+
+	errorStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FF8FA3"))
+
+	warningStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FFA500"))
 )
 
-// CmdFlags - Struct to hold CLI flags
-// for example --help or -h help
-type CmdFlags struct {
-	Help    bool
-	Depth   int
-	Verbose bool
-}
+// ErrUsage is returned by a command run with the wrong arguments; its usage is printed.
+var ErrUsage = errors.New("invalid arguments")
+
+// Command is a subcommand of the CLI, such as "sync" or "capture".
+type Command struct {
+	Name     string
+	Aliases  []string
+	Usage    string   // arguments after the name, e.g. "<path> [flags]"
+	Short    string   // one-line description
+	Long     string   // optional details shown by "help <command>"
+	Examples []string // full command lines without the program name
+	Hidden   bool     // left out of help and completion
+
+	// Complete returns candidates for the next positional argument, given the ones before it.
+	Complete func(cfg *config.Config, args []string) []string
+
+	// FlagValues lists the valid values of flags, offered by shell completion.
+	FlagValues map[string][]string
 
-// CmdParams - Struct to hold CLI commands
-type CmdParams struct {
-	Command string
+	// Setup defines the command's flags on fs and returns the function running it
+	// with its positional arguments. Flag defaults may come from cfg.
+	Setup func(fs *flag.FlagSet, cfg *config.Config) func(args []string) error
 }
 
-// ParseFlags - Parses CLI flags
-func ParseFlags() *CmdFlags {
-	cf := &CmdFlags{}
+// registry holds every command, in registration order.
+var registry []*Command
 
-	// Define flags
-	flag.BoolVar(&cf.Help, "help", false, "Show help message")
-	flag.IntVar(&cf.Depth, "depth", 1, "Set max depth for file tree (-1 for unlimited)")
-	flag.BoolVar(&cf.Verbose, "verbose", false, "Enable verbose logging")
+func init() {
+	Register(
+		helpCommand,
+		authCommand,
+		captureCommand,
+		completionCommand,
+		completeCommand,
+		configCommand,
+		reindexCommand,
+		statusCommand,
+		syncCommand,
+		treeCommand,
+		validateCommand,
+	)
+}
 
-	// Parse known flags
-	flag.Parse()
+// Register adds commands to the CLI.
+func Register(cmds ...*Command) {
+	registry = append(registry, cmds...)
+}
 
-	return cf
+// Commands returns the visible commands sorted by name.
+func Commands() []*Command {
+	var cmds []*Command
+	for _, cmd := range registry {
+		if !cmd.Hidden {
+			cmds = append(cmds, cmd)
+		}
+	}
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].Name < cmds[j].Name })
+	return cmds
 }
 
-// ParseCommands - Detects a valid command (ignoring flags)
-func ParseCommands() *CmdParams {
-	cp := &CmdParams{}
-
-	// Get non-flag arguments (commands)
-	args := flag.Args()
-	if len(args) > 0 {
-		command := args[0]
-		switch command {
-		case "auth", "sync", "status":
-			cp.Command = command
-		default:
-			fmt.Printf("Unknown command: %s\n", command)
-			fmt.Println("Use '--help' to see available commands.")
-			os.Exit(1)
+// LookupCommand finds a command by name or alias.
+func LookupCommand(name string) (*Command, bool) {
+	for _, cmd := range registry {
+		if cmd.Name == name {
+			return cmd, true
+		}
+		for _, alias := range cmd.Aliases {
+			if alias == name {
+				return cmd, true
+			}
 		}
 	}
+	return nil, false
+}
 
-	return cp
+// newFlagSet returns the flag set of a command with its flags defined.
+func newFlagSet(cmd *Command, cfg *config.Config) (*flag.FlagSet, func([]string) error) {
+	fs := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs, cmd.Setup(fs, cfg)
 }
 
-// Execute - Runs commands and flags
-func Execute(cf *CmdFlags, cp *CmdParams) {
-	// Handle flags first
-	if cf.Help {
-		PrintHelp()
+// Execute - Runs the command selected by the configuration, if any
+func Execute(cfg *config.Config) {
+	if cfg.Command == "" {
+		if cfg.Help {
+			PrintHelp()
+			os.Exit(0)
+		}
+		return
+	}
+
+	cmd, ok := LookupCommand(cfg.Command)
+	if !ok {
+		fmt.Printf("Unknown command: %s\n", cfg.Command)
+		if suggestions := suggest(cfg.Command); len(suggestions) > 0 {
+			fmt.Printf("Did you mean %s?\n", strings.Join(suggestions, " or "))
+		}
+		fmt.Println("Use '--help' to see available commands.")
+		os.Exit(1)
+	}
+	if cfg.Help {
+		printCommandHelp(cmd, cfg)
+		os.Exit(0)
+	}
+
+	fs, run := newFlagSet(cmd, cfg)
+	args, err := parseInterspersed(fs, cfg.Args)
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		printCommandHelp(cmd, cfg)
 		os.Exit(0)
+	case err != nil:
+		usageError(cmd, err)
 	}
 
-	// Handle commands
-	switch cp.Command {
-	case "auth":
-		fmt.Println("Initializing authentication...")
-	case "sync":
-		fmt.Println("Syncing cloud changes with local machine...")
-	case "status":
-		fmt.Println("Checking system status...")
+	if err := run(args); err != nil {
+		if errors.Is(err, ErrUsage) {
+			usageError(cmd, err)
+		}
+		fmt.Printf("%s: %v\n", cmd.Name, err)
+		os.Exit(1)
 	}
 }
 
-// PrintHelp - Displays help menu
-func PrintHelp() {
-	fmt.Println(headlineStyles.MarginTop(0).Render("USAGE:"))
-	fmt.Printf("  [command] [flags]\n")
+// usageError reports wrong arguments with the command's usage and exits.
+func usageError(cmd *Command, err error) {
+	fmt.Printf("%s: %v\n", cmd.Name, err)
+	fmt.Printf("Usage: open-template %s %s\n", cmd.Name, cmd.Usage)
+	fmt.Printf("Run 'open-template help %s' for details.\n", cmd.Name)
+	os.Exit(2)
+}
 
-	fmt.Printf("%v\n", headlineStyles.Render("Commands:"))
-	fmt.Printf("%v\t%v\n", commandStyle.Render("auth"), descriptionStyle.Render("Initialize authentication"))
-	fmt.Printf("%v\t%v\n", commandStyle.Render("sync"), descriptionStyle.Render("Sync cloud changes on the local machine"))
-	fmt.Printf("%v\t%v\n", commandStyle.Render("status"), descriptionStyle.Render("Show system and sync status"))
+// parseInterspersed parses flags that may appear before, between or after positional arguments,
+// e.g. "capture ./app --name svc". Everything after "--" is positional, and so is a negative
+// number, as in "config set depth -1". It returns the positional arguments.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var rest []string
+	for i, arg := range args {
+		if arg == "--" {
+			args, rest = args[:i], args[i+1:]
+			break
+		}
+	}
 
-	fmt.Println(headlineStyles.Render("Flags:"))
-	fmt.Printf("%v\t%v\n", commandStyle.Render("--help"), descriptionStyle.Render("Show this help message"))
-	fmt.Printf("%v\t%v\n", commandStyle.Render("--depth n"), descriptionStyle.Render("Set depth of file tree visualization (-1 for unlimited)"))
-	fmt.Printf("%v\t%v\n", commandStyle.Render("--config"), descriptionStyle.Render("Specify config file path"))
-	fmt.Printf("%v\t%v\n", commandStyle.Render("--verbose"), descriptionStyle.Render("Enable verbose logging"))
+	var positional []string
+	for {
+		for len(args) > 0 && isNegativeNumber(args[0]) {
+			positional, args = append(positional, args[0]), args[1:]
+		}
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
 
-	fmt.Println(headlineStyles.Render("Examples:"))
-	fmt.Println("  go run main.go auth")
-	fmt.Println("  go run main.go sync")
-	fmt.Println("  go run main.go status")
-	fmt.Println("  go run main.go --depth=2 --verbose")
+// isNegativeNumber reports whether arg is a negative number rather than a flag.
+func isNegativeNumber(arg string) bool {
+	if len(arg) < 2 || arg[0] != '-' || !strings.ContainsRune("0123456789.", rune(arg[1])) {
+		return false
+	}
+	_, err := strconv.ParseFloat(arg, 64)
+	return err == nil
+}
+
+// HoistGlobalFlags moves global flags given after the command in front of it, so that
+// "status --verbose" works like "--verbose status". A flag the command defines itself,
+// such as "tree --depth", stays with the command, as does everything after "--".
+// args are the command-line arguments without the program name.
+func HoistGlobalFlags(args []string) []string {
+	globals := globalFlags()
+	i := 0
+	for i < len(args) && strings.HasPrefix(args[i], "-") && args[i] != "-" && args[i] != "--" {
+		if takesValue(globals, args[i]) {
+			i++
+		}
+		i++
+	}
+	if i >= len(args) {
+		return args
+	}
+	cmd, ok := LookupCommand(args[i])
+	if !ok {
+		return args
+	}
+	fs, _ := newFlagSet(cmd, config.Default())
 
+	hoisted := append([]string(nil), args[:i]...)
+	rest := []string{args[i]}
+	for j := i + 1; j < len(args); j++ {
+		w := args[j]
+		if w == "--" {
+			rest = append(rest, args[j:]...)
+			break
+		}
+		name, _, _ := strings.Cut(strings.TrimLeft(w, "-"), "=")
+		switch {
+		case !strings.HasPrefix(w, "-") || w == "-" || fs.Lookup(name) != nil || !isFlag(globals, name):
+			rest = append(rest, w)
+		case takesValue(globals, w) && j+1 < len(args):
+			hoisted = append(hoisted, w, args[j+1])
+			j++
+		default:
+			hoisted = append(hoisted, w)
+		}
+	}
+	return append(hoisted, rest...)
+}
+
+// isFlag reports whether name is one of flags.
+func isFlag(flags []*flag.Flag, name string) bool {
+	for _, f := range flags {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// suggest returns the commands whose name or alias is close to the mistyped name.
+func suggest(name string) []string {
+	var suggestions []string
+	for _, cmd := range Commands() {
+		for _, candidate := range append([]string{cmd.Name}, cmd.Aliases...) {
+			if levenshtein(name, candidate) <= 2 || len(name) >= 2 && strings.HasPrefix(candidate, name) {
+				suggestions = append(suggestions, fmt.Sprintf("%q", cmd.Name))
+				break
+			}
+		}
+	}
+	return suggestions
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
 }
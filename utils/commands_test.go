@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"flag"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestHoistGlobalFlags(t *testing.T) {
+	tests := []struct {
+		args, want string
+	}{
+		{"status --verbose", "--verbose status"},
+		{"status --format json --log-level=debug", "--log-level=debug status --format json"},
+		{"--depth 2 validate go-service --ui plain --max-size 10", "--depth 2 --ui plain validate go-service --max-size 10"},
+		{"tree go-service --depth -1 --template-dirs /t", "--template-dirs /t tree go-service --depth -1"},
+		{"sync --remote fork --sync-remote up", "--sync-remote up sync --remote fork"},
+		{"capture ./app -- --verbose", "capture ./app -- --verbose"},
+		{"__complete -- status --verbose", "__complete -- status --verbose"},
+		{"status --unknown --verbose", "--verbose status --unknown"},
+		{"status --config", "--config status"},
+		{"nosuchcommand --verbose", "nosuchcommand --verbose"},
+		{"--verbose", "--verbose"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		got := HoistGlobalFlags(strings.Fields(tt.args))
+		if want := strings.Fields(tt.want); !slices.Equal(got, want) {
+			t.Errorf("HoistGlobalFlags(%q) = %q, want %q", tt.args, got, want)
+		}
+	}
+}
+
+func TestParseInterspersed(t *testing.T) {
+	tests := []struct {
+		args, want string
+		name       string // value of --name after parsing
+	}{
+		{"set depth -1", "set depth -1", ""},
+		{"set depth -0.5 --name x", "set depth -0.5", "x"},
+		{"./app --name svc", "./app", "svc"},
+		{"--name -1 ./app", "./app", "-1"},
+		{"-1 ./app", "-1 ./app", ""},
+		{"./app -- --name svc", "./app --name svc", ""},
+	}
+	for _, tt := range tests {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		name := fs.String("name", "", "")
+		got, err := parseInterspersed(fs, strings.Fields(tt.args))
+		if err != nil {
+			t.Errorf("parseInterspersed(%q): %v", tt.args, err)
+			continue
+		}
+		if want := strings.Fields(tt.want); !slices.Equal(got, want) || *name != tt.name {
+			t.Errorf("parseInterspersed(%q) = %q with --name %q, want %q with %q", tt.args, got, *name, want, tt.name)
+		}
+	}
+
+	// An unknown flag is still an error.
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if _, err := parseInterspersed(fs, []string{"set", "depth", "-x"}); err == nil {
+		t.Error("parseInterspersed accepted the unknown flag -x")
+	}
+}
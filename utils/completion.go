@@ -0,0 +1,271 @@
+package utils
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"open-template/internal/config"
+)
+
+// shells maps each supported shell to its completion script. The scripts call back into
+// the binary ("open-template __complete -- <words>") so candidates such as template names stay live.
+var shells = map[string]string{
+	"bash": `# bash completion for open-template
+# Load with: source <(open-template completion bash)
+_open_template() {
+    # Split the line ourselves: COMP_WORDS breaks "--flag=value" apart at the "=".
+    local line="${COMP_LINE:0:COMP_POINT}"
+    local -a words
+    read -ra words <<< "$line"
+    [[ "$line" == *" " ]] && words+=("")
+
+    local IFS=$'\n'
+    COMPREPLY=($(open-template __complete -- "${words[@]:1}" 2>/dev/null))
+
+    # bash only replaces the text after the "=", so drop the "--flag=" part of candidates.
+    # ${words[-1]} needs bash 4.3; macOS still ships 3.2.
+    local last="${words[${#words[@]}-1]}"
+    if [[ "$last" == *=* && "${COMP_WORDS[COMP_CWORD]}" != "$last" ]]; then
+        COMPREPLY=("${COMPREPLY[@]#*=}")
+    fi
+}
+complete -o default -F _open_template open-template
+`,
+	"zsh": `#compdef open-template
+# zsh completion for open-template
+# Load with: source <(open-template completion zsh), after compinit
+_open_template() {
+    local -a candidates
+    candidates=("${(@f)$(open-template __complete -- "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    if [[ -z "${candidates[1]}" ]]; then
+        _files
+    else
+        compadd -- "${candidates[@]}"
+    fi
+}
+compdef _open_template open-template
+`,
+	"fish": `# fish completion for open-template
+# Load with: open-template completion fish | source
+function __open_template_complete
+    set -l tokens (commandline -opc) (commandline -ct)
+    open-template __complete -- $tokens[2..-1] 2>/dev/null
+end
+complete -c open-template -f -a '(__open_template_complete)'
+`,
+}
+
+// completionCommand prints the completion script of a shell.
+var completionCommand = &Command{
+	Name:  "completion",
+	Usage: "bash|zsh|fish",
+	Short: "Print a shell completion script",
+	Long: "Completes commands, flags, template names and flag values such as --on-conflict.\n" +
+		"bash:  source <(open-template completion bash)\n" +
+		"zsh:   source <(open-template completion zsh)\n" +
+		"fish:  open-template completion fish | source",
+	Examples: []string{
+		"completion bash > /etc/bash_completion.d/open-template",
+		"completion fish > ~/.config/fish/completions/open-template.fish",
+	},
+	Complete: func(cfg *config.Config, args []string) []string {
+		if len(args) > 0 {
+			return nil
+		}
+		return []string{"bash", "fish", "zsh"}
+	},
+	Setup: func(fs *flag.FlagSet, cfg *config.Config) func([]string) error {
+		return func(args []string) error {
+			if len(args) != 1 {
+				return ErrUsage
+			}
+			script, ok := shells[args[0]]
+			if !ok {
+				return fmt.Errorf("%w: unsupported shell %q", ErrUsage, args[0])
+			}
+			fmt.Print(script)
+			return nil
+		}
+	},
+}
+
+// completeCommand is called by the completion scripts with the words typed so far,
+// the last one being the word under the cursor. It prints one candidate per line.
+var completeCommand = &Command{
+	Name:   "__complete",
+	Usage:  "-- <words...>",
+	Short:  "List completion candidates for the shell scripts",
+	Hidden: true,
+	Setup: func(fs *flag.FlagSet, cfg *config.Config) func([]string) error {
+		return func(words []string) error {
+			for _, c := range complete(cfg, words) {
+				fmt.Println(c)
+			}
+			return nil
+		}
+	},
+}
+
+// complete returns the candidates for the last of words.
+func complete(cfg *config.Config, words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	cur, before := words[len(words)-1], words[:len(words)-1]
+
+	// Skip global flags to find the command.
+	globals, globalValues := globalFlags(), globalFlagValues()
+	i := 0
+	for i < len(before) && strings.HasPrefix(before[i], "-") {
+		if takesValue(globals, before[i]) {
+			if i+1 == len(before) {
+				return filterPrefix(globalValues[strings.TrimLeft(before[i], "-")], cur)
+			}
+			i++
+		}
+		i++
+	}
+	if i >= len(before) {
+		if strings.HasPrefix(cur, "-") {
+			return completeFlag(cur, globals, globalValues)
+		}
+		var names []string
+		for _, cmd := range Commands() {
+			names = append(names, cmd.Name)
+		}
+		return filterPrefix(names, cur)
+	}
+
+	cmd, ok := LookupCommand(before[i])
+	if !ok {
+		return nil
+	}
+	fs, _ := newFlagSet(cmd, cfg)
+	var flags []*flag.Flag
+	fs.VisitAll(func(f *flag.Flag) { flags = append(flags, f) })
+
+	// Global flags are accepted after the command too, unless it has a flag of the same name.
+	values := map[string][]string{}
+	for _, f := range globals {
+		if fs.Lookup(f.Name) == nil {
+			flags = append(flags, f)
+			values[f.Name] = globalValues[f.Name]
+		}
+	}
+	for name, v := range cmd.FlagValues {
+		values[name] = v
+	}
+
+	// Collect positional arguments, noting a flag waiting for its value.
+	var args []string
+	pending := ""
+	for _, w := range before[i+1:] {
+		switch {
+		case pending != "":
+			pending = ""
+		case strings.HasPrefix(w, "-") && w != "-":
+			if takesValue(flags, w) {
+				pending = strings.TrimLeft(w, "-")
+			}
+		default:
+			args = append(args, w)
+		}
+	}
+
+	switch {
+	case pending != "":
+		return filterPrefix(values[pending], cur)
+	case strings.HasPrefix(cur, "-"):
+		return completeFlag(cur, flags, values)
+	case cmd.Complete != nil:
+		return filterPrefix(cmd.Complete(cfg, args), cur)
+	}
+	return nil
+}
+
+// completeFlag completes a flag name, or its value when cur is "--flag=...".
+func completeFlag(cur string, flags []*flag.Flag, values map[string][]string) []string {
+	if name, value, ok := strings.Cut(strings.TrimLeft(cur, "-"), "="); ok {
+		var out []string
+		for _, v := range filterPrefix(values[name], value) {
+			out = append(out, "--"+name+"="+v)
+		}
+		return out
+	}
+	var names []string
+	for _, f := range flags {
+		names = append(names, "--"+f.Name)
+	}
+	return filterPrefix(names, cur)
+}
+
+// globalFlags returns the flags accepted before the command, and after it by HoistGlobalFlags.
+func globalFlags() []*flag.Flag {
+	fs := flag.NewFlagSet("open-template", flag.ContinueOnError)
+	fs.Bool("help", false, "")
+	fs.String("config", "", "")
+	for _, k := range config.Keys {
+		if k.Kind == config.KindBool {
+			fs.Bool(k.FlagName(), false, "")
+		} else {
+			fs.String(k.FlagName(), "", "")
+		}
+	}
+	var flags []*flag.Flag
+	fs.VisitAll(func(f *flag.Flag) { flags = append(flags, f) })
+	return flags
+}
+
+// globalFlagValues returns the allowed values of the global flags that have a fixed set.
+func globalFlagValues() map[string][]string {
+	values := map[string][]string{}
+	for _, k := range config.Keys {
+		if len(k.Values) > 0 {
+			values[k.FlagName()] = k.Values
+		}
+	}
+	return values
+}
+
+// takesValue reports whether word is a flag that consumes the next word as its value.
+func takesValue(flags []*flag.Flag, word string) bool {
+	name := strings.TrimLeft(word, "-")
+	if strings.Contains(name, "=") {
+		return false
+	}
+	for _, f := range flags {
+		if f.Name == name {
+			b, ok := f.Value.(interface{ IsBoolFlag() bool })
+			return !ok || !b.IsBoolFlag()
+		}
+	}
+	return false
+}
+
+// filterPrefix returns the candidates starting with prefix.
+func filterPrefix(candidates []string, prefix string) []string {
+	var out []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// CompleteTemplateName completes the first positional argument with the live template names.
+// It only lists the template roots, which is much cheaper than loading the index.
+func CompleteTemplateName(cfg *config.Config, args []string) []string {
+	if len(args) > 0 {
+		return nil
+	}
+	templates, err := FindTemplates(cfg.TemplateDirs)
+	if err != nil {
+		return nil
+	}
+	names := TemplateNames(templates)
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"open-template/internal/config"
+)
+
+func TestComplete(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"go-service", "go-cli", "rust-cli", ".git"} {
+		if err := os.Mkdir(filepath.Join(root, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := config.Default()
+	cfg.TemplateDirs = []string{root}
+
+	tests := []struct {
+		words string // words after the program name; a trailing "|" stands for an empty word
+		want  string
+	}{
+		{"sta", "status"},
+		{"--ui |", "auto tui plain"},
+		{"--ui p", "plain"},
+		{"--on-cancel=r", "--on-cancel=rollback"},
+		{"--log-level=", "--log-level=debug --log-level=info --log-level=warn --log-level=error"},
+		{"--verbose --depth 2 st", "status"},
+		{"--template-d", "--template-dirs"},
+		{"tree go", "go-cli go-service"},
+		{"tree |", "go-cli go-service rust-cli"},
+		{"tree go-cli |", ""},
+		{"tree --format a", "ascii"},
+		{"tree --format=m", "--format=markdown"},
+		{"tree --dep", "--depth"},
+		{"status --ui |", "auto tui plain"},
+		{"status --on-cancel=k", "--on-cancel=keep"},
+		{"status --verb", "--verbose"},
+		{"sync --remote |", ""},
+		{"completion |", "bash fish zsh"},
+		{"nosuchcommand |", ""},
+	}
+	for _, tt := range tests {
+		words := strings.Fields(strings.TrimSuffix(tt.words, "|"))
+		if strings.HasSuffix(tt.words, "|") {
+			words = append(words, "")
+		}
+		got := complete(cfg, words)
+		if want := strings.Fields(tt.want); !slices.Equal(got, want) {
+			t.Errorf("complete(%q) = %q, want %q", tt.words, got, want)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package utils
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"open-template/internal/config"
+)
+
+// configCommand lists, reads and edits settings in the config file.
+var configCommand = &Command{
+	Name:  "config",
+	Usage: "list | get <key> | set <key> <value> | unset <key> | edit | path | validate",
+	Short: "Manage settings in the config file",
+	Long: "list shows every setting with its source: default, file, env or flag. set and unset edit\n" +
+		"the config file in place, keeping comments. validate reports unknown keys and type errors\n" +
+		"with line numbers.",
+	Examples: []string{
+		"config list",
+		"config set depth 2",
+		"config get template_dirs",
+		"config validate",
+	},
+	Complete: func(cfg *config.Config, args []string) []string {
+		switch {
+		case len(args) == 0:
+			return []string{"edit", "get", "list", "path", "set", "unset", "validate"}
+		case len(args) == 1 && (args[0] == "get" || args[0] == "set" || args[0] == "unset"):
+			var names []string
+			for _, k := range config.Keys {
+				names = append(names, k.Name)
+			}
+			return names
+		case len(args) == 2 && args[0] == "set":
+			if k, ok := config.LookupKey(args[1]); ok {
+				return k.Values
+			}
+		}
+		return nil
+	},
+	Setup: func(fs *flag.FlagSet, cfg *config.Config) func([]string) error {
+		return func(args []string) error {
+			return runConfig(args, cfg)
+		}
+	},
+}
+
+// runConfig - Lists, reads and edits settings in the config file
+func runConfig(args []string, cfg *config.Config) error {
+	if len(args) == 0 {
+		return ErrUsage
+	}
+
+	// lookup resolves the key argument of get, set and unset.
+	lookup := func(want int) (*config.Key, error) {
+		if len(args) != want {
+			return nil, ErrUsage
+		}
+		k, ok := config.LookupKey(args[1])
+		if !ok {
+			return nil, fmt.Errorf("unknown key %q; run \"config list\" to see all keys", args[1])
+		}
+		return k, nil
+	}
+
+	switch args[0] {
+	case "list":
+		for _, k := range config.Keys {
+			fmt.Printf("%s = %s\t%s\n", k.Name, config.FormatValue(k.Get(cfg)), descriptionStyle.Faint(true).Render(sourceLabel(cfg, k)))
+		}
+
+	case "get":
+		k, err := lookup(2)
+		if err != nil {
+			return err
+		}
+		fmt.Println(plainValue(k.Get(cfg)))
+
+	case "set":
+		k, err := lookup(3)
+		if err != nil {
+			return err
+		}
+		v, err := k.ParseString(args[2])
+		if err != nil {
+			return err
+		}
+		if err := config.SetInFile(cfg.FilePath, k, v); err != nil {
+			return err
+		}
+		fmt.Printf("%s = %s written to %s\n", k.Name, config.FormatValue(v), cfg.FilePath)
+		warnOverridden(cfg, k)
+
+	case "unset":
+		k, err := lookup(2)
+		if err != nil {
+			return err
+		}
+		removed, err := config.UnsetInFile(cfg.FilePath, k)
+		if err != nil {
+			return err
+		}
+		if !removed {
+			fmt.Printf("%s is not set in %s\n", k.Name, cfg.FilePath)
+			return nil
+		}
+		fmt.Printf("%s removed from %s\n", k.Name, cfg.FilePath)
+		warnOverridden(cfg, k)
+
+	case "edit":
+		if len(args) != 1 {
+			return ErrUsage
+		}
+		return editConfig(cfg.FilePath)
+
+	case "path":
+		if len(args) != 1 {
+			return ErrUsage
+		}
+		fmt.Println(cfg.FilePath)
+
+	case "validate":
+		if len(args) != 1 {
+			return ErrUsage
+		}
+		return validateConfig(cfg.FilePath)
+
+	default:
+		return fmt.Errorf("%w: unknown action %q", ErrUsage, args[0])
+	}
+	return nil
+}
+
+// sourceLabel describes where a setting's value came from.
+func sourceLabel(cfg *config.Config, k *config.Key) string {
+	switch src := cfg.Sources[k.Name]; src {
+	case config.SourceFile:
+		return "file " + cfg.ConfigFile
+	case config.SourceEnv:
+		return "env " + k.EnvVar()
+	case config.SourceFlag:
+		return "flag --" + k.FlagName()
+	default:
+		return string(src)
+	}
+}
+
+// plainValue formats a setting for scripts: strings unquoted, path lists joined like $PATH.
+func plainValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, string(filepath.ListSeparator))
+	}
+	return config.FormatValue(v)
+}
+
+// warnOverridden tells the user when the file no longer decides a setting's value.
+func warnOverridden(cfg *config.Config, k *config.Key) {
+	if _, ok := os.LookupEnv(k.EnvVar()); ok {
+		fmt.Println(warningStyle.Render(fmt.Sprintf("note: %s is overridden by the %s environment variable", k.Name, k.EnvVar())))
+	}
+}
+
+// editConfig opens the config file in $VISUAL or $EDITOR and validates it afterwards.
+func editConfig(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		var sb strings.Builder
+		sb.WriteString("# open-template configuration\n")
+		for _, k := range config.Keys {
+			fmt.Fprintf(&sb, "\n# %s\n# %s = %s\n", k.Help, k.Name, config.FormatValue(k.Default))
+		}
+		if err := os.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+			return err
+		}
+	}
+
+	// The editor may carry arguments, e.g. EDITOR="code --wait".
+	parts := strings.Fields(editor)
+	cmd := exec.Command(parts[0], append(parts[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("running %s: %w", editor, err)
+	}
+	return validateConfig(path)
+}
+
+// validateConfig reports every problem in the config file with its line number.
+func validateConfig(path string) error {
+	problems, err := config.Validate(path)
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("%s does not exist; built-in defaults are used\n", path)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, p := range problems {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("%s:%v", path, strings.TrimPrefix(p.Error(), "line "))))
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d problems found in %s", len(problems), path)
+	}
+	fmt.Printf("%s is valid\n", path)
+	return nil
+}
@@ -1,18 +1,234 @@
 package utils
 
-import "strings"
+import (
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
 
-// FilterTemplates returns a list of templates whose names contain the query (case-insensitive).
-func FilterTemplates(templates []string, query string) []string {
-	if query == "" {
-		return templates
+	"open-template/internal/index"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Scores of the fuzzy matcher, in the spirit of fzf: every matched character scores,
+// matches at the start of words and runs of consecutive matches score more, gaps cost.
+const (
+	scoreMatch       = 16
+	bonusBoundary    = 8 // match at the start of the name or after a separator
+	bonusCamel       = 7 // match at an upper-case letter following a lower-case one
+	bonusPrefix      = 8 // match of the first query character at the start of the name
+	bonusConsecutive = 8 // match right after the previous match
+	penaltyGapStart  = 3
+	penaltyGapExtend = 1
+	noMatch          = -1 << 30
+)
+
+// scoreMetadata is the score of a query term found in a template's description or tags
+// rather than its name, so name matches rank first.
+const scoreMetadata = 1
+
+// Match is a template matching a search query.
+type Match struct {
+	Name      string
+	Score     int
+	Positions []int  // indexes of the matched runes in Name
+	Detail    string // why the template matched if not by its name: description or file path
+}
+
+// SearchTemplates returns the indexed templates matching the query, best match first.
+// Each word of the query must fuzzy-match the name or appear in the description or tags;
+// "tag:<tag>" and "lang:<language>" words only keep templates with that tag or language.
+func SearchTemplates(idx *index.Index, query string) []Match {
+	var terms, tags, langs []string
+	for _, word := range strings.Fields(query) {
+		switch {
+		case strings.HasPrefix(word, "tag:") && len(word) > len("tag:"):
+			tags = append(tags, strings.TrimPrefix(word, "tag:"))
+		case strings.HasPrefix(word, "lang:") && len(word) > len("lang:"):
+			langs = append(langs, strings.TrimPrefix(word, "lang:"))
+		default:
+			terms = append(terms, word)
+		}
+	}
+
+	var result []Match
+entries:
+	for _, e := range idx.Entries {
+		for _, tag := range tags {
+			if !e.HasTag(tag) {
+				continue entries
+			}
+		}
+		for _, lang := range langs {
+			if !strings.EqualFold(e.Language, lang) {
+				continue entries
+			}
+		}
+
+		m := Match{Name: e.Name}
+		for _, term := range terms {
+			if score, positions, ok := FuzzyMatch(e.Name, term); ok {
+				m.Score += score
+				m.Positions = append(m.Positions, positions...)
+				continue
+			}
+			if !containsFold(e.Description, term) && !slices.ContainsFunc(e.Tags, func(tag string) bool { return containsFold(tag, term) }) {
+				continue entries
+			}
+			m.Score += scoreMetadata
+			m.Detail = e.Description
+		}
+		result = append(result, m)
+	}
+	if len(terms) > 0 {
+		sortMatches(result)
 	}
-	var result []string
-	lowerQuery := strings.ToLower(query)
-	for _, tmpl := range templates {
-		if strings.Contains(strings.ToLower(tmpl), lowerQuery) {
-			result = append(result, tmpl)
+	return result
+}
+
+// FindInTemplates returns the indexed templates with a file whose path contains text, or whose
+// words contain those of text (see index.Entry.FindFile), in index order. The first such file
+// is the match's Detail. It only reads the index, so it is cheap enough to run on every key.
+func FindInTemplates(idx *index.Index, text string) []Match {
+	var result []Match
+	for _, e := range idx.Entries {
+		if text == "" {
+			result = append(result, Match{Name: e.Name})
+		} else if path, ok := e.FindFile(text); ok {
+			result = append(result, Match{Name: e.Name, Detail: path})
 		}
 	}
 	return result
 }
+
+// sortMatches orders matches by score, then shorter names first.
+func sortMatches(matches []Match) {
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return len(matches[i].Name) < len(matches[j].Name)
+	})
+}
+
+// containsFold reports whether substr is within s, ignoring case.
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// FuzzyMatch reports whether the characters of query appear in s in order, ignoring case,
+// with the score of the best such alignment and the rune indexes it matches.
+func FuzzyMatch(s, query string) (score int, positions []int, ok bool) {
+	text, pattern := []rune(s), []rune(strings.ToLower(query))
+	if len(pattern) == 0 {
+		return 0, nil, true
+	}
+	if len(pattern) > len(text) {
+		return 0, nil, false
+	}
+
+	// best[i][j] is the best score of matching pattern[:i+1] with pattern[i] at text[j];
+	// from[i][j] is where pattern[i-1] was matched in that alignment.
+	best := make([][]int, len(pattern))
+	from := make([][]int, len(pattern))
+	for i := range pattern {
+		best[i] = make([]int, len(text))
+		from[i] = make([]int, len(text))
+		for j := range text {
+			best[i][j] = noMatch
+			if unicode.ToLower(text[j]) != pattern[i] {
+				continue
+			}
+			bonus := charBonus(text, j)
+			if i == 0 {
+				if j == 0 {
+					bonus += bonusPrefix
+				}
+				best[i][j] = scoreMatch + bonus
+				continue
+			}
+			for k := i - 1; k < j; k++ {
+				if best[i-1][k] == noMatch {
+					continue
+				}
+				s := best[i-1][k] + scoreMatch + bonus
+				if k == j-1 {
+					s += bonusConsecutive
+				} else {
+					s -= penaltyGapStart + penaltyGapExtend*(j-k-2)
+				}
+				if s > best[i][j] {
+					best[i][j], from[i][j] = s, k
+				}
+			}
+		}
+	}
+
+	last := len(pattern) - 1
+	end := -1
+	for j := range text {
+		if best[last][j] != noMatch && (end < 0 || best[last][j] > best[last][end]) {
+			end = j
+		}
+	}
+	if end < 0 {
+		return 0, nil, false
+	}
+
+	positions = make([]int, len(pattern))
+	for i, j := last, end; i >= 0; i-- {
+		positions[i] = j
+		j = from[i][j]
+	}
+	return best[last][end], positions, true
+}
+
+// charBonus returns the bonus for matching text[j], based on the character before it.
+func charBonus(text []rune, j int) int {
+	if j == 0 {
+		return bonusBoundary
+	}
+	prev, cur := text[j-1], text[j]
+	switch {
+	case strings.ContainsRune("-_./ ", prev):
+		return bonusBoundary
+	case unicode.IsLower(prev) && unicode.IsUpper(cur):
+		return bonusCamel
+	case !unicode.IsDigit(prev) && unicode.IsDigit(cur):
+		return bonusCamel
+	}
+	return 0
+}
+
+// HighlightMatch renders s with base, and the runes at positions with match.
+func HighlightMatch(s string, positions []int, base, match lipgloss.Style) string {
+	matched := make(map[int]bool, len(positions))
+	for _, p := range positions {
+		matched[p] = true
+	}
+
+	var sb strings.Builder
+	var run []rune
+	runMatched := false
+	flush := func() {
+		if len(run) == 0 {
+			return
+		}
+		style := base
+		if runMatched {
+			style = match
+		}
+		sb.WriteString(style.Render(string(run)))
+		run = run[:0]
+	}
+	for i, r := range []rune(s) {
+		if matched[i] != runMatched {
+			flush()
+			runMatched = matched[i]
+		}
+		run = append(run, r)
+	}
+	flush()
+	return sb.String()
+}
@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"slices"
+	"testing"
+
+	"open-template/internal/index"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		s, query  string
+		ok        bool
+		positions []int
+	}{
+		{"go-service", "", true, nil},
+		{"go-service", "gs", true, []int{0, 3}},
+		{"go-service", "GS", true, []int{0, 3}},
+		{"go-service", "svc", true, []int{3, 6, 8}},
+		{"go-service", "sg", false, nil},
+		{"go", "gox", false, nil},
+		{"ReactApp", "app", true, []int{5, 6, 7}},
+		{"python3-cli", "3", true, []int{6}},
+	}
+	for _, tt := range tests {
+		_, positions, ok := FuzzyMatch(tt.s, tt.query)
+		if ok != tt.ok || !slices.Equal(positions, tt.positions) {
+			t.Errorf("FuzzyMatch(%q, %q) = %v, %v; want %v, %v", tt.s, tt.query, positions, ok, tt.positions, tt.ok)
+		}
+	}
+}
+
+func TestFuzzyMatchRanking(t *testing.T) {
+	// Each query must score the first name above the second.
+	tests := []struct {
+		query, better, worse string
+	}{
+		{"api", "api-gateway", "rapid"},               // word start over middle of a word
+		{"svc", "svc-template", "service-template"},   // consecutive over gaps
+		{"ga", "go-api", "grpc-cache"},                // short gap over long gap
+		{"app", "ReactApp", "reactapplication-shell"}, // camel-case boundary
+	}
+	for _, tt := range tests {
+		better, _, ok1 := FuzzyMatch(tt.better, tt.query)
+		worse, _, ok2 := FuzzyMatch(tt.worse, tt.query)
+		if !ok1 || !ok2 || better <= worse {
+			t.Errorf("query %q: %q scored %d, %q scored %d; want the first higher", tt.query, tt.better, better, tt.worse, worse)
+		}
+	}
+}
+
+func TestSearchTemplates(t *testing.T) {
+	idx := &index.Index{Entries: []*index.Entry{
+		{Name: "go-service", Description: "HTTP service", Tags: []string{"backend"}, Language: "Go"},
+		{Name: "react-app", Description: "Single page app", Tags: []string{"frontend"}, Language: "TypeScript"},
+		{Name: "go-cli", Description: "Command-line tool", Tags: []string{"cli"}, Language: "Go"},
+	}}
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"go-service", "react-app", "go-cli"}},
+		{"gocli", []string{"go-cli"}},
+		{"go", []string{"go-cli", "go-service"}},
+		{"http", []string{"go-service"}},
+		{"frontend", []string{"react-app"}},
+		{"lang:go", []string{"go-service", "go-cli"}},
+		{"tag:cli", []string{"go-cli"}},
+		{"lang:go tag:backend", []string{"go-service"}},
+		{"tag:nope", nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, m := range SearchTemplates(idx, tt.query) {
+			got = append(got, m.Name)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("SearchTemplates(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestFindInTemplates(t *testing.T) {
+	idx := &index.Index{Entries: []*index.Entry{
+		{Name: "go-service", Files: []index.File{
+			{Path: "README.md", Words: index.Words("Run go mod tidy, then connect to PostgreSQL.", 0)},
+			{Path: "main.go", Words: index.Words("package main", 0)},
+		}},
+		{Name: "web", Files: []index.File{
+			{Path: "Dockerfile", Words: index.Words("FROM node", 0)},
+		}},
+	}}
+	tests := []struct {
+		text string
+		want []Match
+	}{
+		{"", []Match{{Name: "go-service"}, {Name: "web"}}},
+		{"dockerfile", []Match{{Name: "web", Detail: "Dockerfile"}}},
+		{"postgres", []Match{{Name: "go-service", Detail: "README.md"}}},
+		{"MAIN", []Match{{Name: "go-service", Detail: "main.go"}}},
+		{"node postgres", nil},
+	}
+	for _, tt := range tests {
+		got := FindInTemplates(idx, tt.text)
+		if !slices.EqualFunc(got, tt.want, func(a, b Match) bool { return a.Name == b.Name && a.Detail == b.Detail }) {
+			t.Errorf("FindInTemplates(%q) = %+v, want %+v", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package utils
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"open-template/internal/config"
+)
+
+// helpCommand prints the help of the CLI or of a single command.
+var helpCommand = &Command{
+	Name:     "help",
+	Usage:    "[command]",
+	Short:    "Show help for open-template or one of its commands",
+	Examples: []string{"help capture"},
+	Complete: func(cfg *config.Config, args []string) []string {
+		if len(args) > 0 {
+			return nil
+		}
+		var names []string
+		for _, cmd := range Commands() {
+			names = append(names, cmd.Name)
+		}
+		return names
+	},
+	Setup: func(fs *flag.FlagSet, cfg *config.Config) func([]string) error {
+		return func(args []string) error {
+			switch len(args) {
+			case 0:
+				PrintHelp()
+				return nil
+			case 1:
+				cmd, ok := LookupCommand(args[0])
+				if !ok {
+					if suggestions := suggest(args[0]); len(suggestions) > 0 {
+						return fmt.Errorf("unknown command %q; did you mean %s?", args[0], strings.Join(suggestions, " or "))
+					}
+					return fmt.Errorf("unknown command %q", args[0])
+				}
+				printCommandHelp(cmd, cfg)
+				return nil
+			}
+			return ErrUsage
+		}
+	},
+}
+
+// row prints a two-column help line, padding the first column to width.
+func row(width int, left, right string) {
+	fmt.Printf("%v  %v\n", commandStyle.Width(width+4).Render(left), descriptionStyle.Render(right))
+}
+
+// PrintHelp - Displays help menu, generated from the command registry and the config keys
+func PrintHelp() {
+	fmt.Println(headlineStyles.MarginTop(0).Render("USAGE:"))
+	fmt.Printf("  open-template [flags] [command] [command flags]\n")
+
+	cmds := Commands()
+	width := 0
+	for _, cmd := range cmds {
+		width = max(width, len(cmd.Name))
+	}
+	fmt.Printf("%v\n", headlineStyles.Render("Commands:"))
+	for _, cmd := range cmds {
+		short := cmd.Short
+		if len(cmd.Aliases) > 0 {
+			short += " (alias: " + strings.Join(cmd.Aliases, ", ") + ")"
+		}
+		row(width, cmd.Name, short)
+	}
+
+	type flagLine struct{ name, help string }
+	flags := []flagLine{
+		{"--help", "Show this help message"},
+		{"--config path", "Specify config file path (default $XDG_CONFIG_HOME/open-template/config.toml)"},
+	}
+	for _, k := range config.Keys {
+		flags = append(flags, flagLine{"--" + k.FlagName() + placeholder(k.Kind), k.Help})
+	}
+	width = 0
+	for _, f := range flags {
+		width = max(width, len(f.name))
+	}
+	fmt.Println(headlineStyles.Render("Flags:"))
+	for _, f := range flags {
+		row(width, f.name, f.help)
+	}
+	fmt.Println()
+	fmt.Println("  Every setting can also be set in the config file or with an OPEN_TEMPLATE_* environment")
+	fmt.Println("  variable, e.g. OPEN_TEMPLATE_DEPTH=2. Flags override the environment, which overrides the file.")
+
+	fmt.Println(headlineStyles.Render("Examples:"))
+	for _, cmd := range cmds {
+		if len(cmd.Examples) > 0 {
+			fmt.Println("  open-template " + cmd.Examples[0])
+		}
+	}
+	fmt.Println("  open-template --depth=2 --verbose")
+	fmt.Println()
+	fmt.Println("  Run 'open-template help <command>' for the flags and examples of a command.")
+}
+
+// printCommandHelp - Displays the help of a single command
+func printCommandHelp(cmd *Command, cfg *config.Config) {
+	fmt.Println(headlineStyles.MarginTop(0).Render("USAGE:"))
+	fmt.Printf("  open-template %s %s\n\n", cmd.Name, cmd.Usage)
+	fmt.Println("  " + cmd.Short)
+	if cmd.Long != "" {
+		fmt.Println()
+		for _, line := range strings.Split(cmd.Long, "\n") {
+			fmt.Println("  " + line)
+		}
+	}
+	if len(cmd.Aliases) > 0 {
+		fmt.Printf("\n  Aliases: %s\n", strings.Join(cmd.Aliases, ", "))
+	}
+
+	fs, _ := newFlagSet(cmd, cfg)
+	type flagLine struct{ name, help string }
+	var flags []flagLine
+	width := 0
+	fs.VisitAll(func(f *flag.Flag) {
+		kind, usage := flag.UnquoteUsage(f)
+		name := "--" + f.Name
+		if kind != "" {
+			name += " " + kind
+		}
+		if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "0" {
+			usage += fmt.Sprintf(" (default %s)", f.DefValue)
+		}
+		flags = append(flags, flagLine{name, usage})
+		width = max(width, len(name))
+	})
+	if len(flags) > 0 {
+		fmt.Println(headlineStyles.Render("Flags:"))
+		for _, f := range flags {
+			row(width, f.name, f.help)
+		}
+	}
+
+	if len(cmd.Examples) > 0 {
+		fmt.Println(headlineStyles.Render("Examples:"))
+		for _, ex := range cmd.Examples {
+			fmt.Println("  open-template " + ex)
+		}
+	}
+}
+
+// placeholder returns the argument shown after a setting's flag in help.
+func placeholder(kind config.Kind) string {
+	switch kind {
+	case config.KindInt:
+		return " n"
+	case config.KindPaths:
+		return " a:b"
+	case config.KindString:
+		return " value"
+	}
+	return ""
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"open-template/internal/config"
+	"open-template/internal/index"
+	"open-template/internal/paths"
+)
+
+// reindexCommand rebuilds the template index from scratch.
+var reindexCommand = &Command{
+	Name:  "reindex",
+	Usage: "",
+	Short: "Rebuild the template index",
+	Long: "The index of template metadata, files and content hashes lives in the cache directory\n" +
+		"and is refreshed automatically when templates change. reindex discards it and indexes\n" +
+		"every template again, e.g. after editing templates on a file system with unreliable\n" +
+		"modification times.",
+	Examples: []string{"reindex"},
+	Setup: func(fs *flag.FlagSet, cfg *config.Config) func([]string) error {
+		return func(args []string) error {
+			if len(args) > 0 {
+				return ErrUsage
+			}
+			file, err := paths.IndexFile()
+			if err != nil {
+				return err
+			}
+
+			start := time.Now()
+			idx, err := index.Rebuild(file, cfg.TemplateDirs)
+			if err != nil {
+				return err
+			}
+			files := 0
+			for _, e := range idx.Entries {
+				files += len(e.Files)
+			}
+			fmt.Printf("Indexed %d templates (%d files) in %s\n", len(idx.Entries), files, time.Since(start).Round(time.Millisecond))
+			fmt.Println(descriptionStyle.Render("Index: " + file))
+			return nil
+		}
+	},
+}
+
+// LoadIndex returns the template index of the configured roots, refreshed from the disk.
+func LoadIndex(cfg *config.Config) (*index.Index, error) {
+	file, err := paths.IndexFile()
+	if err != nil {
+		return nil, err
+	}
+	return index.Open(file, cfg.TemplateDirs)
+}
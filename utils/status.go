@@ -0,0 +1,208 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"open-template/internal/config"
+	"open-template/internal/gitsync"
+	"open-template/internal/manifest"
+	"open-template/internal/paths"
+)
+
+// statusReport is the output of the status command; it is also its JSON form.
+type statusReport struct {
+	ConfigFile string       `json:"config_file"`
+	ConfigDir  string       `json:"config_dir"`
+	CacheDir   string       `json:"cache_dir"`
+	StateDir   string       `json:"state_dir"`
+	Roots      []rootStatus `json:"roots"`
+}
+
+// rootStatus describes one template root.
+type rootStatus struct {
+	Path           string            `json:"path"`
+	Error          string            `json:"error,omitempty"`
+	Templates      int               `json:"templates"`
+	ManifestErrors map[string]string `json:"manifest_errors,omitempty"`
+	Sync           *syncStatus       `json:"sync,omitempty"`
+}
+
+// syncStatus is the git state of a template root.
+type syncStatus struct {
+	Error           string     `json:"error,omitempty"`
+	Remote          string     `json:"remote,omitempty"`
+	Branch          string     `json:"branch,omitempty"`
+	Ahead           int        `json:"ahead"`
+	Behind          int        `json:"behind"`
+	Dirty           bool       `json:"dirty"`
+	LastSync        *time.Time `json:"last_sync,omitempty"`
+	EditedTemplates []string   `json:"edited_templates,omitempty"`
+}
+
+// statusCommand reports configuration, template roots and their sync state.
+var statusCommand = &Command{
+	Name:  "status",
+	Usage: "[flags]",
+	Short: "Show template roots, manifest errors and sync state",
+	Long: "Reports the config file and directories in use and, for each template root, the number of\n" +
+		"templates, manifest errors, sync state relative to the remote, last sync time and\n" +
+		"templates with uncommitted edits.",
+	Examples: []string{
+		"status",
+		"status --format json",
+	},
+	FlagValues: map[string][]string{"format": {"text", "json"}},
+	Setup: func(fs *flag.FlagSet, cfg *config.Config) func([]string) error {
+		format := fs.String("format", "text", "Output format: text or json")
+		remote := fs.String("remote", cfg.SyncRemote, "Git remote to compare with")
+
+		return func(args []string) error {
+			if len(args) > 0 {
+				return ErrUsage
+			}
+			if *format != "text" && *format != "json" {
+				return fmt.Errorf("%w: unknown format %q; use text or json", ErrUsage, *format)
+			}
+
+			report := statusReport{ConfigFile: cfg.ConfigFile}
+			report.ConfigDir, _ = paths.ConfigDir()
+			report.CacheDir, _ = paths.CacheDir()
+			report.StateDir, _ = paths.StateDir()
+			for _, root := range cfg.TemplateDirs {
+				report.Roots = append(report.Roots, collectRootStatus(root, *remote))
+			}
+
+			if *format == "json" {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(report)
+			}
+			printStatus(report)
+			return nil
+		}
+	},
+}
+
+// collectRootStatus gathers the status of a single template root.
+func collectRootStatus(root, remote string) rootStatus {
+	rs := rootStatus{Path: root}
+	templates, err := LoadTemplates(root)
+	if err != nil {
+		rs.Error = err.Error()
+		return rs
+	}
+	rs.Templates = len(templates)
+
+	for _, name := range templates {
+		if _, err := manifest.Load(filepath.Join(root, name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			if rs.ManifestErrors == nil {
+				rs.ManifestErrors = map[string]string{}
+			}
+			rs.ManifestErrors[name] = err.Error()
+		}
+	}
+
+	rs.Sync = &syncStatus{}
+	st, err := gitsync.State(root, remote)
+	if err != nil {
+		rs.Sync.Error = err.Error()
+		return rs
+	}
+	rs.Sync.Remote, rs.Sync.Branch = st.Remote, st.Branch
+	rs.Sync.Ahead, rs.Sync.Behind = st.Ahead, st.Behind
+	rs.Sync.Dirty = len(st.Dirty) > 0
+	if last, err := gitsync.LastFetch(root); err == nil && !last.IsZero() {
+		rs.Sync.LastSync = &last
+	}
+
+	rs.Sync.EditedTemplates = editedTemplates(st.Dirty)
+	return rs
+}
+
+// editedTemplates groups uncommitted paths, relative to the template root, by the template
+// they belong to. Files at the top of the root and hidden directories are not templates.
+func editedTemplates(dirty []string) []string {
+	edited := map[string]bool{}
+	for _, path := range dirty {
+		name, _, inDir := strings.Cut(filepath.ToSlash(path), "/")
+		if inDir && !strings.HasPrefix(name, ".") {
+			edited[name] = true
+		}
+	}
+	var names []string
+	for name := range edited {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// printStatus renders the report for humans.
+func printStatus(report statusReport) {
+	configFile := report.ConfigFile
+	if configFile == "" {
+		configFile = "none (using built-in defaults)"
+	}
+	fmt.Printf("%v %s\n", headlineStyles.Margin(0).Render("Config file:"), configFile)
+	fmt.Printf("%v %s\n", headlineStyles.Margin(0).Render("Config dir: "), report.ConfigDir)
+	fmt.Printf("%v %s\n", headlineStyles.Margin(0).Render("Cache dir:  "), report.CacheDir)
+	fmt.Printf("%v %s\n", headlineStyles.Margin(0).Render("State dir:  "), report.StateDir)
+
+	for _, rs := range report.Roots {
+		fmt.Println(headlineStyles.Margin(1, 0, 0, 0).Render("Template root: " + rs.Path))
+		if rs.Error != "" {
+			fmt.Println(errorStyle.Render("  " + rs.Error))
+			continue
+		}
+		fmt.Printf("  Templates: %d\n", rs.Templates)
+		for _, name := range sortedNames(rs.ManifestErrors) {
+			fmt.Println(errorStyle.Render(fmt.Sprintf("  Manifest error in %s: %s", name, rs.ManifestErrors[name])))
+		}
+
+		s := rs.Sync
+		if s.Error != "" {
+			fmt.Println(warningStyle.Render("  Sync: " + s.Error))
+			continue
+		}
+		state := "up to date"
+		switch {
+		case s.Ahead > 0 && s.Behind > 0:
+			state = fmt.Sprintf("diverged (%d ahead, %d behind)", s.Ahead, s.Behind)
+		case s.Ahead > 0:
+			state = fmt.Sprintf("%d ahead", s.Ahead)
+		case s.Behind > 0:
+			state = fmt.Sprintf("%d behind", s.Behind)
+		}
+		if s.Dirty {
+			state += ", dirty"
+		}
+		fmt.Printf("  Sync: %s/%s %s\n", s.Remote, s.Branch, state)
+		if s.LastSync != nil {
+			fmt.Printf("  Last sync: %s\n", s.LastSync.Format(time.RFC1123))
+		} else {
+			fmt.Println("  Last sync: never")
+		}
+		if len(s.EditedTemplates) > 0 {
+			fmt.Printf("  Uncommitted edits: %s\n", strings.Join(s.EditedTemplates, ", "))
+		}
+	}
+}
+
+// sortedNames returns the keys of m in order.
+func sortedNames(m map[string]string) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestEditedTemplates(t *testing.T) {
+	tests := []struct {
+		name  string
+		dirty []string
+		want  []string
+	}{
+		{"none", nil, nil},
+		{"grouped by template", []string{"go-service/main.go", "go-service/cmd/x.go", "api/README.md"}, []string{"api", "go-service"}},
+		{"top-level files", []string{"README.md", ".gitignore", "svc/a.txt"}, []string{"svc"}},
+		{"hidden directories", []string{".github/workflows/ci.yml", ".git-hooks/pre-commit"}, nil},
+		{"deleted template", []string{"old/template.json"}, []string{"old"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := editedTemplates(tt.dirty); !slices.Equal(got, tt.want) {
+				t.Errorf("editedTemplates(%q) = %q, want %q", tt.dirty, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"flag"
+	"fmt"
+
+	"open-template/internal/config"
+	"open-template/internal/gitsync"
+)
+
+// syncCommand fast-forwards the template roots from their git remote and optionally pushes local changes.
+var syncCommand = &Command{
+	Name:  "sync",
+	Usage: "[flags]",
+	Short: "Fast-forward templates from their git remote",
+	Long: "Treats each template root as a git working copy: fetches from the remote and fast-forwards.\n" +
+		"With --push, local edits are committed and pushed. Divergent histories are reported, never merged.",
+	Examples: []string{
+		"sync",
+		"sync --push --message \"Add go-service template\"",
+	},
+	Setup: func(fs *flag.FlagSet, cfg *config.Config) func([]string) error {
+		remote := fs.String("remote", cfg.SyncRemote, "Git remote to sync with")
+		push := fs.Bool("push", false, "Commit local template changes and push them to the remote")
+		message := fs.String("message", "Update templates", "Commit message used for local changes with --push")
+
+		return func(args []string) error {
+			if len(args) > 0 {
+				return ErrUsage
+			}
+
+			failed := 0
+			for _, root := range cfg.TemplateDirs {
+				if len(cfg.TemplateDirs) > 1 {
+					fmt.Println(headlineStyles.Margin(1, 0, 0, 0).Render(root))
+				}
+				res, err := gitsync.Sync(root, gitsync.Options{Remote: *remote, Push: *push, Message: *message, Token: registryToken})
+				if err != nil {
+					fmt.Println(errorStyle.Render(err.Error()))
+					failed++
+					continue
+				}
+
+				switch {
+				case res.FastForwarded:
+					fmt.Printf("Pulled %d commits from %s/%s\n", res.Behind, res.Remote, res.Branch)
+				default:
+					fmt.Printf("Templates are up to date with %s/%s\n", res.Remote, res.Branch)
+				}
+				if res.Committed {
+					fmt.Println("Committed local template changes")
+				}
+				if res.Pushed {
+					fmt.Printf("Pushed local changes to %s/%s\n", res.Remote, res.Branch)
+				} else if res.Ahead > 0 {
+					fmt.Printf("%d local commits not pushed; run sync --push to publish them\n", res.Ahead)
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d template roots failed to sync", failed, len(cfg.TemplateDirs))
+			}
+			return nil
+		}
+	},
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Template is a template directory found in one of the template roots.
+type Template struct {
+	Name string
+	Root string
+}
+
+// Dir returns the path of the template directory.
+func (t Template) Dir() string {
+	return filepath.Join(t.Root, t.Name)
+}
+
+// LoadTemplates returns a slice of template names from the templates directory.
+// Hidden directories such as .git are not templates.
+func LoadTemplates(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var templates []string
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			templates = append(templates, entry.Name())
+		}
+	}
+	return templates, nil
+}
+
+// FindTemplates returns the templates of every root. Like $PATH, a template in an earlier
+// root shadows one with the same name in a later root. Missing roots are skipped.
+func FindTemplates(roots []string) ([]Template, error) {
+	var templates []Template
+	seen := map[string]bool{}
+	for _, root := range roots {
+		names, err := LoadTemplates(root)
+		if os.IsNotExist(err) {
+			slog.Warn("template root does not exist", "root", root)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		slog.Debug("loaded templates", "root", root, "count", len(names))
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				templates = append(templates, Template{Name: name, Root: root})
+			}
+		}
+	}
+	return templates, nil
+}
+
+// FindTemplate returns the template with the given name from the first root that has it.
+func FindTemplate(roots []string, name string) (Template, bool) {
+	for _, root := range roots {
+		if info, err := os.Stat(filepath.Join(root, name)); err == nil && info.IsDir() {
+			return Template{Name: name, Root: root}, true
+		}
+	}
+	return Template{}, false
+}
+
+// TemplateNames returns the names of the templates.
+func TemplateNames(templates []Template) []string {
+	names := make([]string, len(templates))
+	for i, t := range templates {
+		names[i] = t.Name
+	}
+	return names
+}
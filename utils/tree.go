@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"open-template/internal/config"
+	"open-template/internal/tree"
+)
+
+// treeCommand prints the file tree of a template, as shown in the TUI preview, or exports it.
+var treeCommand = &Command{
+	Name:  "tree",
+	Usage: "<template|dir> [flags]",
+	Short: "Print or export the file tree of a template",
+	Long: "Prints the tree of the named template from the template index, or of a directory given\n" +
+		"by its path. --format color draws it like the TUI preview; ascii, markdown, json and html\n" +
+		"export it for documentation or other tools.",
+	Examples: []string{
+		"tree go-service --depth -1",
+		"tree go-service --format markdown > TREE.md",
+		"tree ./my-service --format json",
+	},
+	Complete:   CompleteTemplateName,
+	FlagValues: map[string][]string{"format": tree.Formats},
+	Setup: func(fs *flag.FlagSet, cfg *config.Config) func([]string) error {
+		depth := fs.Int("depth", cfg.Depth, "Max depth of the tree (-1 for unlimited)")
+		format := fs.String("format", "color", "Output format: "+strings.Join(tree.Formats, ", "))
+
+		return func(args []string) error {
+			if len(args) != 1 {
+				return ErrUsage
+			}
+			root, err := walkTree(cfg, args[0], *depth)
+			if err != nil {
+				return err
+			}
+			out, err := tree.Render(*format, root)
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrUsage, err)
+			}
+			fmt.Print(out)
+			return nil
+		}
+	},
+}
+
+// walkTree builds the tree of the named template, or of the directory at the given path
+// when it is not a template name.
+func walkTree(cfg *config.Config, arg string, depth int) (*tree.Node, error) {
+	idx, err := LoadIndex(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if e, ok := idx.Lookup(arg); ok {
+		root, err := tree.Walk(e.Name, tree.Entry(e), depth)
+		if err == nil && len(e.Dirs) > 0 {
+			root.Mode = e.Dirs[0].Mode
+		}
+		return root, err
+	}
+	info, err := os.Stat(arg)
+	if err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("no template or directory named %q", arg)
+	}
+	abs, err := filepath.Abs(arg)
+	if err != nil {
+		return nil, err
+	}
+	root, err := tree.Walk(filepath.Base(abs), tree.Disk(arg), depth)
+	if err == nil {
+		root.Mode = info.Mode()
+	}
+	return root, err
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"flag"
+	"fmt"
+
+	"open-template/internal/config"
+	"open-template/internal/validate"
+)
+
+// validateCommand checks templates for problems, failing only if errors were found.
+var validateCommand = &Command{
+	Name:    "validate",
+	Aliases: []string{"lint"},
+	Usage:   "[template...] [flags]",
+	Short:   "Check templates for broken files, symlinks and variables",
+	Long: "Parses every manifest, templated file and path, and checks that the variables used are\n" +
+		"declared and the variables declared are used. Exits non-zero only if errors were found.\n" +
+		"Templates are read through the index, so .git is skipped as it is when generating.",
+	Examples: []string{
+		"validate",
+		"validate go-service --max-size 1048576",
+	},
+	Complete: func(cfg *config.Config, args []string) []string {
+		return CompleteTemplateName(cfg, nil)
+	},
+	Setup: func(fs *flag.FlagSet, cfg *config.Config) func([]string) error {
+		maxSize := fs.Int64("max-size", validate.DefaultMaxFileSize, "Warn about template files larger than this many bytes")
+
+		return func(names []string) error {
+			idx, err := LoadIndex(cfg)
+			if err != nil {
+				return err
+			}
+			if len(names) == 0 {
+				names = idx.Names()
+			}
+
+			errorCount, warningCount := 0, 0
+			for _, name := range names {
+				e, ok := idx.Lookup(name)
+				if !ok {
+					fmt.Println(errorStyle.Render(fmt.Sprintf("%s: error: no such template", name)))
+					errorCount++
+					continue
+				}
+				for _, issue := range validate.Template(e, validate.Options{MaxFileSize: *maxSize}) {
+					if issue.Severity == validate.Error {
+						fmt.Println(errorStyle.Render(issue.String()))
+						errorCount++
+					} else {
+						fmt.Println(warningStyle.Render(issue.String()))
+						warningCount++
+					}
+				}
+			}
+
+			fmt.Printf("%d templates checked: %d errors, %d warnings\n", len(names), errorCount, warningCount)
+			if errorCount > 0 {
+				return fmt.Errorf("%d errors found", errorCount)
+			}
+			return nil
+		}
+	},
+}
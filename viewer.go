@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"open-template/internal/index"
+	"open-template/internal/manifest"
+	"open-template/internal/preview"
+	style "open-template/internal/ui/style"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// fileLoadedMsg delivers a file opened from the preview pane.
+type fileLoadedMsg struct {
+	viewer *fileViewer
+}
+
+// fileViewer shows a template file, highlighted, in a scrollable viewport.
+type fileViewer struct {
+	template string
+	path     string // relative to the template directory
+	file     *preview.File
+	manifest *manifest.Manifest // for the defaults of the sample values, if any
+	err      error              // why the file could not be read
+
+	rendered     bool        // show the placeholders rendered with sample values
+	renderedPath string      // path with its placeholders rendered
+	lines        [2][]string // numbered, highlighted contents: as is, and rendered
+	renderErr    error       // why the placeholders could not be rendered
+
+	view viewport.Model
+}
+
+// openFile returns a command reading and highlighting a file of the template in the background,
+// for a viewer showing width columns and height lines of it.
+func openFile(e *index.Entry, rel string, width, height int) tea.Cmd {
+	return func() tea.Msg {
+		v := &fileViewer{template: e.Name, path: rel, view: viewport.New(width, height)}
+		v.file, v.err = preview.Load(filepath.Join(e.Dir(), filepath.FromSlash(rel)), rel)
+		if m, err := manifest.Load(e.Dir()); err == nil {
+			v.manifest = m
+		}
+		v.show()
+		return fileLoadedMsg{viewer: v}
+	}
+}
+
+// show puts the contents for the current mode in the viewport, highlighting them the
+// first time they are shown.
+func (v *fileViewer) show() {
+	switch {
+	case v.err != nil:
+		v.view.SetContent(style.ErrorStyle.Render(v.err.Error()))
+		return
+	case v.file.Binary:
+		v.view.SetContent("Binary file not shown: " + v.file.Describe())
+		return
+	}
+
+	mode := 0
+	if v.rendered {
+		mode = 1
+	}
+	if v.lines[mode] == nil {
+		name, text := v.file.Name, v.file.Text
+		if v.rendered {
+			var err error
+			if name, text, err = v.file.Render(v.manifest); err != nil {
+				v.renderErr, v.rendered = err, false
+				return
+			}
+			v.renderedPath = name
+		}
+		v.lines[mode] = numberLines(preview.Highlight(name, text))
+	}
+	// Lines are cut at the width of the viewer, so each takes a single row.
+	lines := make([]string, len(v.lines[mode]))
+	for i, line := range v.lines[mode] {
+		lines[i] = ansi.Truncate(line, v.view.Width, "…")
+	}
+	v.view.SetContent(strings.Join(lines, "\n"))
+}
+
+// resize fits the viewer to width columns and height lines.
+func (v *fileViewer) resize(width, height int) {
+	v.view.Width, v.view.Height = width, height
+	v.show()
+}
+
+// numberLines prefixes the lines with their numbers.
+func numberLines(lines []string) []string {
+	width := len(fmt.Sprint(len(lines)))
+	numbered := make([]string, len(lines))
+	for i, line := range lines {
+		// Tabs are expanded, or the terminal would widen the line past where it is cut.
+		numbered[i] = commandStyle.Render(fmt.Sprintf("%*d │ ", width, i+1)) + strings.ReplaceAll(line, "\t", "    ")
+	}
+	return numbered
+}
+
+// update handles the keys of the viewer and reports whether it should be closed.
+func (v *fileViewer) update(msg tea.KeyMsg) (closed bool, cmd tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q", "backspace", "left", "h":
+		return true, nil
+	case "r":
+		if v.err == nil && !v.file.Binary {
+			v.rendered = !v.rendered
+			v.renderErr = nil
+			v.show()
+		}
+	case "home", "g":
+		v.view.GotoTop()
+	case "end", "G":
+		v.view.GotoBottom()
+	default:
+		v.view, cmd = v.view.Update(msg)
+	}
+	return false, cmd
+}
+
+// render draws the viewer: a title line, the contents and the position in them.
+func (v *fileViewer) render() string {
+	title := v.template + "/" + v.path
+	if v.rendered {
+		title = v.template + "/" + v.renderedPath
+	}
+	var details []string
+	if v.file != nil {
+		if v.file.Language != "" {
+			details = append(details, v.file.Language)
+		}
+		details = append(details, preview.FormatSize(v.file.Size))
+	}
+	if v.rendered {
+		details = append(details, "rendered with sample values")
+	}
+	header := style.PromptStyle.Render(title) + " " + commandStyle.Render(strings.Join(details, " · "))
+
+	footer := "esc back"
+	if v.err == nil && !v.file.Binary {
+		first := v.view.YOffset + 1
+		last := min(v.view.YOffset+v.view.Height, v.view.TotalLineCount())
+		footer = fmt.Sprintf("%d-%d of %d • ↑/↓ scroll • r rendered • esc back", first, last, v.view.TotalLineCount())
+	}
+	footer = commandStyle.Render(footer)
+	if v.renderErr != nil {
+		footer = style.ErrorStyle.Render("Cannot render: "+v.renderErr.Error()) + "\n" + footer
+	}
+	return header + "\n\n" + v.view.View() + "\n\n" + footer
+}
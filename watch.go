@@ -0,0 +1,84 @@
+package main
+
+import (
+	"log/slog"
+	"time"
+
+	"open-template/internal/index"
+	"open-template/internal/paths"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// reloadInterval is how often the template roots are checked for changes while a template
+// is being selected. Polling works on every file system, network mounts included; each check
+// only compares directories (see index.Poll) and walks the templates whose directories changed.
+const reloadInterval = 2 * time.Second
+
+// reloadTickMsg starts a check of the template roots.
+type reloadTickMsg struct{}
+
+// indexReloadedMsg delivers the refreshed index, if the templates changed.
+type indexReloadedMsg struct {
+	index   *index.Index
+	changed bool
+	err     error
+}
+
+// scheduleReload waits for the next check of the template roots.
+func scheduleReload() tea.Cmd {
+	return tea.Tick(reloadInterval, func(time.Time) tea.Msg { return reloadTickMsg{} })
+}
+
+// reloadIndex refreshes a copy of the index in the background, saving it if it changed.
+func reloadIndex(idx *index.Index, roots []string) tea.Cmd {
+	return func() tea.Msg {
+		next := *idx
+		changed, err := next.Poll(roots)
+		if err != nil || !changed {
+			return indexReloadedMsg{err: err}
+		}
+		if file, err := paths.IndexFile(); err == nil {
+			if err := next.Save(file); err != nil {
+				slog.Warn("could not save template index", "file", file, "error", err)
+			}
+		}
+		return indexReloadedMsg{index: &next, changed: true}
+	}
+}
+
+// setIndex shows the templates of idx, keeping the cursors on the templates they were on
+// when those still exist. Previews are rendered again, as any template may have changed.
+func (m *model) setIndex(idx *index.Index) {
+	current := ""
+	if len(m.templates) > 0 {
+		current = m.templates[m.cursor]
+	}
+	m.index = idx
+	m.templates = idx.Names()
+	m.templateDirs = map[string]string{}
+	for _, e := range idx.Entries {
+		m.templateDirs[e.Name] = e.Dir()
+	}
+	m.cursor = min(m.cursor, max(0, len(m.templates)-1))
+	for i, name := range m.templates {
+		if name == current {
+			m.cursor = i
+		}
+	}
+
+	if m.searchMode {
+		current := ""
+		if len(m.searchResults) > 0 {
+			current = m.searchResults[m.searchCursor].Name
+		}
+		m.searchResults = m.search()
+		m.searchCursor = min(m.searchCursor, max(0, len(m.searchResults)-1))
+		for i, match := range m.searchResults {
+			if match.Name == current {
+				m.searchCursor = i
+			}
+		}
+	}
+	m.previews = newPreviewCache()
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"open-template/internal/index"
+)
+
+// testIndex returns an index of the named templates, in that order.
+func testIndex(names ...string) *index.Index {
+	idx := &index.Index{}
+	for _, name := range names {
+		idx.Entries = append(idx.Entries, &index.Entry{Name: name, Root: "/templates"})
+	}
+	return idx
+}
+
+func TestSetIndex(t *testing.T) {
+	tests := []struct {
+		name       string
+		before     []string
+		cursor     int
+		search     bool
+		after      []string
+		wantCursor int
+	}{
+		{"template added above", []string{"api", "web"}, 1, false, []string{"api", "cli", "web"}, 2},
+		{"template removed above", []string{"api", "cli", "web"}, 2, false, []string{"cli", "web"}, 1},
+		{"template under the cursor removed", []string{"api", "cli", "web"}, 2, false, []string{"api", "cli"}, 1},
+		{"every template removed", []string{"api"}, 0, false, nil, 0},
+		{"search results", []string{"api", "web"}, 1, true, []string{"api", "cli", "web"}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := model{searchMode: tt.search}
+			m.setIndex(testIndex(tt.before...))
+			if tt.search {
+				m.searchCursor = tt.cursor
+			} else {
+				m.cursor = tt.cursor
+			}
+
+			m.setIndex(testIndex(tt.after...))
+			if !slices.Equal(m.templates, tt.after) {
+				t.Errorf("templates = %q, want %q", m.templates, tt.after)
+			}
+			for _, name := range tt.after {
+				if m.templateDirs[name] == "" {
+					t.Errorf("no directory for %s", name)
+				}
+			}
+			got := m.cursor
+			if tt.search {
+				got = m.searchCursor
+			}
+			if got != tt.wantCursor {
+				t.Errorf("cursor = %d, want %d", got, tt.wantCursor)
+			}
+		})
+	}
+}